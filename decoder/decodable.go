@@ -22,6 +22,8 @@ func Of(from any) (Decodable, error) {
 	return opt, nil
 }
 
+// DecodeHookFunc returns a hook running [Decodable] implementations of the target type.
+// The default hook chain already covers Decodable, see [To] for the precedence.
 func DecodeHookFunc() mapstructure.DecodeHookFunc {
 	return func(from, to reflect.Type, val interface{}) (interface{}, error) {
 		opt, ok := reflect.New(to).Interface().(Decodable)
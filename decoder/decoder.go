@@ -1,75 +1,195 @@
 package decoder
 
 import (
+	"context"
 	"errors"
-	"time"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
 
 	"github.com/fmotalleb/go-tools/decoder/hooks"
+	"github.com/fmotalleb/go-tools/decoder/types"
 	"github.com/fmotalleb/go-tools/template"
 	"github.com/go-viper/mapstructure/v2"
 )
 
-func GetHooks() []mapstructure.DecodeHookFunc {
-	return []mapstructure.DecodeHookFunc{
-		hooks.EnvSubst(),
-		mapstructure.StringToTimeDurationHookFunc(),
-		mapstructure.StringToTimeHookFunc(time.RFC3339),
+// Decoder is a reusable, preconfigured decoder.
+// The hook chain is composed once in [New] and shared by every call, so a
+// single Decoder is safe for concurrent use. Calls given a context recompose it
+// so variant options are decoded with that context.
+type Decoder struct {
+	tagName         string
+	weaklyTyped     bool
+	envSubst        bool
+	decodeNil       bool
+	sliceSep        string
+	kvSep           string
+	splitOpts       []hooks.SplitOption
+	timeLayouts     []string
+	squash          bool
+	squashTagOption string
+	prependHooks    []mapstructure.DecodeHookFunc
+	appendHooks     []mapstructure.DecodeHookFunc
+	noDefaultHooks  bool
+	registry        *Registry
+	keyPolicy       KeyPolicy
+
+	hook mapstructure.DecodeHookFunc
+	// hooks is the chain hook is composed of, hooks[variantAt] is the variant hook.
+	hooks     []mapstructure.DecodeHookFunc
+	variantAt int
+}
+
+// New creates a [Decoder] using the default configuration altered by opts.
+func New(opts ...Option) *Decoder {
+	d := &Decoder{
+		tagName:         "mapstructure",
+		weaklyTyped:     true,
+		envSubst:        true,
+		decodeNil:       true,
+		sliceSep:        ",",
+		kvSep:           "=",
+		squashTagOption: "squash",
+		registry:        DefaultRegistry(),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	allHooks := make([]mapstructure.DecodeHookFunc, 0)
+	allHooks = append(allHooks, d.prependHooks...)
+	if d.envSubst && !d.noDefaultHooks {
+		allHooks = append(allHooks, hooks.EnvSubst())
+	}
+	allHooks = append(allHooks, d.registry.typeHook())
+	d.variantAt = len(allHooks)
+	allHooks = append(allHooks, d.variantHook(context.Background()))
+	if !d.noDefaultHooks {
+		allHooks = append(allHooks, d.defaultHooks()...)
+	}
+	allHooks = append(allHooks, d.registry.extraHook())
+	allHooks = append(allHooks, d.appendHooks...)
+	d.hooks = allHooks
+	d.hook = mapstructure.ComposeDecodeHookFunc(allHooks...)
+	return d
+}
+
+func (d *Decoder) defaultHooks() []mapstructure.DecodeHookFunc {
+	result := make([]mapstructure.DecodeHookFunc, 0)
+	layouts := append(slices.Clone(types.DefaultTimeLayouts), d.timeLayouts...)
+	result = append(result, selfDecodingHook())
+	result = append(result, hooks.UnitHooks()...)
+	result = append(result, hooks.TimeHooks(layouts...)...)
+	result = append(result, hooks.NetHooks()...)
+	// Unmarshalers run before LooseTypeCaster, which would fail on e.g. a level name for an int based type.
+	result = append(result,
+		unmarshalerHook(),
 		hooks.LooseTypeCaster(),
+	)
+	result = append(result,
 		mapstructure.StringToNetIPAddrPortHookFunc(),
 		mapstructure.StringToNetIPAddrHookFunc(),
-		mapstructure.TextUnmarshallerHookFunc(),
 		mapstructure.StringToURLHookFunc(),
 		mapstructure.StringToIPHookFunc(),
 		mapstructure.StringToIPNetHookFunc(),
-		hooks.StringToSliceHookFunc(","),
+	)
+	if d.sliceSep != "" {
+		result = append(result, hooks.StringToSliceHookFunc(d.sliceSep, d.splitOpts...))
+		if d.kvSep != "" {
+			result = append(result, hooks.StringToMapHookFunc(d.sliceSep, d.kvSep, d.splitOpts...))
+		}
+	}
+	result = append(result,
 		mapstructure.RecursiveStructToMapHookFunc(),
 		// mapstructure.StringToBasicTypeHookFunc(),
-		DecodeHookFunc(),
-	}
+	)
+	return result
 }
 
-func Build[T any](item T, extraHooks ...mapstructure.DecodeHookFunc) (*mapstructure.Decoder, error) {
-	allHooks := GetHooks()
-	allHooks = append(allHooks, hooks.GetExtraHooks()...)
+// Config returns a mapstructure config for this decoder writing into result.
+// extraHooks are run before the decoder's own hook chain.
+func (d *Decoder) Config(result any, extraHooks ...mapstructure.DecodeHookFunc) *mapstructure.DecoderConfig {
+	return d.config(context.Background(), result, extraHooks...)
+}
+
+// config returns the mapstructure config of [Decoder.Config], decoding variant options with ctx.
+func (d *Decoder) config(ctx context.Context, result any, extraHooks ...mapstructure.DecodeHookFunc) *mapstructure.DecoderConfig {
+	hook := d.hook
+	if ctx != context.Background() {
+		chain := slices.Clone(d.hooks)
+		chain[d.variantAt] = d.variantHook(ctx)
+		hook = mapstructure.ComposeDecodeHookFunc(chain...)
+	}
 	if len(extraHooks) != 0 {
-		allHooks = append(extraHooks, allHooks...)
+		chain := make([]mapstructure.DecodeHookFunc, 0, len(extraHooks)+1)
+		chain = append(chain, extraHooks...)
+		hook = mapstructure.ComposeDecodeHookFunc(append(chain, hook)...)
 	}
-	hook := mapstructure.ComposeDecodeHookFunc(
-		allHooks...,
-	)
-
-	decoderConfig := &mapstructure.DecoderConfig{
+	var matchName func(mapKey, fieldName string) bool
+	if d.keyPolicy != nil {
+		matchName = d.matchKey
+	}
+	return &mapstructure.DecoderConfig{
 		Metadata:         nil,
-		Result:           &item,
-		TagName:          "mapstructure",
-		WeaklyTypedInput: true,
+		Result:           result,
+		TagName:          d.tagName,
+		WeaklyTypedInput: d.weaklyTyped,
 		DecodeHook:       hook,
-		DecodeNil:        true,
+		DecodeNil:        d.decodeNil,
+		Squash:           d.squash,
+		SquashTagOption:  d.squashTagOption,
+		MatchName:        matchName,
 	}
-	decoder, err := mapstructure.NewDecoder(decoderConfig)
-	return decoder, err
 }
 
-func Decode(dst any, src any) error {
-	decoder, err := Build(dst)
-	if err != nil {
-		return errors.Join(
-			errors.New("failed to create decoder"),
-			err,
-		)
+// Decode decodes src into dst, which must be a non-nil pointer.
+func (d *Decoder) Decode(dst any, src any) error {
+	return d.decode(context.Background(), dst, src)
+}
+
+// DecodeContext does what [Decoder.Decode] does, logging the use of `alias` and `deprecated` keys
+// through the logger of ctx, see [log.FromContext].
+func (d *Decoder) DecodeContext(ctx context.Context, dst any, src any) error {
+	return d.decode(ctx, dst, src)
+}
+
+// DecodeWithTemplate does what [Decoder.Decode] does, but evaluates every
+// string input as a template against data first, see [template.StringTemplateEvaluate]:
+// data is the first element of the template's dot, `{{ (index . 0).Host }}`.
+// Use [Decoder.DecodeTemplateFields] to only evaluate opted-in fields.
+func (d *Decoder) DecodeWithTemplate(dst any, src any, data any) error {
+	return d.decode(context.Background(), dst, src, template.StringTemplateEvaluate(data))
+}
+
+// DecodeTemplateFields does what [Decoder.Decode] does, then evaluates the fields tagged
+// `template:"true"` against data, see [template.EvaluateOnStruct]. Other strings are left as they are.
+func (d *Decoder) DecodeTemplateFields(dst any, src any, data any) error {
+	if err := d.decode(context.Background(), dst, src); err != nil {
+		return err
 	}
-	if err := decoder.Decode(src); err != nil {
-		return errors.Join(
-			errors.New("failed to decode"),
-			err,
-		)
+	return template.EvaluateOnStruct(dst, data)
+}
+
+func (d *Decoder) decode(ctx context.Context, dst any, src any, extraHooks ...mapstructure.DecodeHookFunc) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("failed to decode: target must be a non-nil pointer, got %T", dst)
 	}
-	return nil
+	resolved, err := d.resolveKeys(ctx, v.Type().Elem(), src, "")
+	if err != nil {
+		return errors.Join(errors.New("failed to decode"), err)
+	}
+	return d.decodeResolved(ctx, dst, resolved, extraHooks...)
 }
 
-func DecodeWithTemplate(dst any, src any, data any) error {
-	hook := template.StringTemplateEvaluate(data)
-	decoder, err := Build(dst, hook)
+// decodeResolved decodes src, whose `alias` keys are already resolved, into the non-nil pointer dst.
+func (d *Decoder) decodeResolved(
+	ctx context.Context,
+	dst any,
+	src any,
+	extraHooks ...mapstructure.DecodeHookFunc,
+) error {
+	decoder, err := mapstructure.NewDecoder(d.config(ctx, dst, extraHooks...))
 	if err != nil {
 		return errors.Join(
 			errors.New("failed to create decoder"),
@@ -84,3 +204,43 @@ func DecodeWithTemplate(dst any, src any, data any) error {
 	}
 	return nil
 }
+
+var defaultDecoder = sync.OnceValue(func() *Decoder { return New() })
+
+// Default returns the shared [Decoder] with the default configuration, backed by [DefaultRegistry].
+func Default() *Decoder {
+	return defaultDecoder()
+}
+
+// GetHooks returns the default hook chain, excluding env substitution and registry hooks.
+func GetHooks() []mapstructure.DecodeHookFunc {
+	return Default().defaultHooks()
+}
+
+// Build creates a mapstructure decoder writing into item using the default configuration.
+// extraHooks are run before the default hook chain.
+func Build[T any](item T, extraHooks ...mapstructure.DecodeHookFunc) (*mapstructure.Decoder, error) {
+	return mapstructure.NewDecoder(Default().Config(&item, extraHooks...))
+}
+
+// Decode decodes src into dst using the [Default] decoder.
+func Decode(dst any, src any) error {
+	return Default().Decode(dst, src)
+}
+
+// DecodeContext decodes src into dst using the [Default] decoder, logging through the logger of ctx.
+func DecodeContext(ctx context.Context, dst any, src any) error {
+	return Default().DecodeContext(ctx, dst, src)
+}
+
+// DecodeWithTemplate decodes src into dst using the [Default] decoder,
+// evaluating every string input as a template against data first.
+func DecodeWithTemplate(dst any, src any, data any) error {
+	return Default().DecodeWithTemplate(dst, src, data)
+}
+
+// DecodeTemplateFields decodes src into dst using the [Default] decoder,
+// then evaluates the fields tagged `template:"true"` against data.
+func DecodeTemplateFields(dst any, src any, data any) error {
+	return Default().DecodeTemplateFields(dst, src, data)
+}
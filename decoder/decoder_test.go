@@ -0,0 +1,170 @@
+package decoder_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/fmotalleb/go-tools/decoder"
+	"github.com/fmotalleb/go-tools/template"
+)
+
+type tagged struct {
+	Name  string        `yaml:"display_name"`
+	Items []string      `yaml:"items"`
+	Wait  time.Duration `yaml:"wait"`
+	When  time.Time     `yaml:"when"`
+}
+
+func TestNew_TagNameAndSeparator(t *testing.T) {
+	d := decoder.New(
+		decoder.WithTagName("yaml"),
+		decoder.WithSliceSeparator(";"),
+		decoder.WithTimeLayouts(time.DateOnly),
+	)
+	var out tagged
+	err := d.Decode(&out, map[string]any{
+		"display_name": "svc",
+		"items":        "a;b;c",
+		"wait":         "5s",
+		"when":         "2024-03-01",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "svc", out.Name)
+	assert.Equal(t, []string{"a", "b", "c"}, out.Items)
+	assert.Equal(t, 5*time.Second, out.Wait)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), out.When)
+}
+
+func TestNew_WithoutEnvSubst(t *testing.T) {
+	t.Setenv("DECODER_TEST_VALUE", "expanded")
+	var expanded, literal string
+
+	assert.NoError(t, decoder.New().Decode(&expanded, "$DECODER_TEST_VALUE"))
+	assert.Equal(t, "expanded", expanded)
+
+	assert.NoError(t, decoder.New(decoder.WithoutEnvSubst()).Decode(&literal, "$DECODER_TEST_VALUE"))
+	assert.Equal(t, "$DECODER_TEST_VALUE", literal)
+}
+
+func TestNew_WithoutWeakTyping(t *testing.T) {
+	var out struct {
+		Flag bool
+	}
+	d := decoder.New(decoder.WithoutWeakTyping(), decoder.WithoutDefaultHooks())
+	assert.Error(t, d.Decode(&out, map[string]any{"flag": 1}))
+	assert.NoError(t, decoder.New().Decode(&out, map[string]any{"flag": 1}))
+	assert.True(t, out.Flag)
+}
+
+type Embedded struct {
+	Port int
+}
+
+type withEmbedded struct {
+	Embedded
+	Host string
+}
+
+func TestNew_Squash(t *testing.T) {
+	input := map[string]any{"host": "localhost", "port": 80}
+
+	var squashed withEmbedded
+	assert.NoError(t, decoder.New(decoder.WithSquash(true)).Decode(&squashed, input))
+	assert.Equal(t, 80, squashed.Port)
+
+	var nested withEmbedded
+	assert.NoError(t, decoder.New().Decode(&nested, input))
+	assert.Equal(t, 0, nested.Port)
+}
+
+func TestNew_HookOrdering(t *testing.T) {
+	var order []string
+	record := func(name string) func(reflect.Type, reflect.Type, any) (any, error) {
+		return func(_, _ reflect.Type, val any) (any, error) {
+			order = append(order, name)
+			return val, nil
+		}
+	}
+	d := decoder.New(
+		decoder.WithoutDefaultHooks(),
+		decoder.WithHooks(record("last")),
+		decoder.WithPrependHooks(record("first")),
+	)
+	var out string
+	assert.NoError(t, d.Decode(&out, "value"))
+	assert.Equal(t, []string{"first", "last"}, order)
+}
+
+func TestDecoder_RejectsNonPointer(t *testing.T) {
+	var out string
+	err := decoder.New().Decode(out, "value")
+	assert.EqualError(t, err, "failed to decode: target must be a non-nil pointer, got string")
+}
+
+func TestDecode_QuotedSlicesAndMaps(t *testing.T) {
+	t.Setenv("DECODER_TEST_LABELS", "team=core,tier=1")
+	var out struct {
+		Hosts  []string
+		Ports  []uint16
+		Labels map[string]string
+		Limits map[string]time.Duration
+		Tiers  map[string]int
+	}
+	err := decoder.Decode(&out, map[string]any{
+		"hosts":  `a.example, "b,c.example" ,`,
+		"ports":  "80, 443",
+		"labels": "$DECODER_TEST_LABELS",
+		"limits": "read=5s,write=1m",
+		"tiers":  "core=1, edge=2",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.example", "b,c.example"}, out.Hosts)
+	assert.Equal(t, []uint16{80, 443}, out.Ports)
+	assert.Equal(t, map[string]string{"team": "core", "tier": "1"}, out.Labels)
+	assert.Equal(t, map[string]time.Duration{"read": 5 * time.Second, "write": time.Minute}, out.Limits)
+	assert.Equal(t, map[string]int{"core": 1, "edge": 2}, out.Tiers)
+
+	var disabled struct{ Labels map[string]string }
+	assert.Error(t, decoder.New(decoder.WithKeyValueSeparator("")).Decode(&disabled, map[string]any{"labels": "a=b"}))
+}
+
+func TestDecodeWithTemplate(t *testing.T) {
+	var out struct {
+		URL  string
+		Port int
+	}
+	// Templates see the data wrapped in a slice.
+	err := decoder.DecodeWithTemplate(&out, map[string]any{
+		"url":  "http://{{ (index . 0).Host }}",
+		"port": "{{ (index . 0).Port }}",
+	}, map[string]any{"Host": "h", "Port": 8080})
+	assert.NoError(t, err)
+	assert.Equal(t, "http://h", out.URL)
+	assert.Equal(t, 8080, out.Port)
+}
+
+func TestDecodeTemplateFields(t *testing.T) {
+	var out struct {
+		URL  string `template:"true"`
+		Note string
+		Body template.String
+	}
+	err := decoder.DecodeTemplateFields(&out, map[string]any{
+		"url":  "https://{{ .Host }}/",
+		"note": "literal {{ braces",
+		"body": "Hello {{ .User }}",
+	}, map[string]any{"Host": "example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/", out.URL)
+	assert.Equal(t, "literal {{ braces", out.Note)
+
+	body, err := out.Body.Render(map[string]any{"User": "ada"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello ada", body)
+
+	encoded, err := decoder.Encode(out)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello {{ .User }}", encoded["Body"])
+}
@@ -0,0 +1,255 @@
+package decoder
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Encodable is implemented by types that encode themselves into a value accepted by their Decode,
+// e.g. the raw definition a value was decoded from. It takes precedence over encoding.TextMarshaler.
+type Encodable interface {
+	Encode() (any, error)
+}
+
+var (
+	encodableType     = reflect.TypeFor[Encodable]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	durationType      = reflect.TypeFor[time.Duration]()
+)
+
+// Encode converts v, a struct or a map (or a pointer to one), into a map that [Decoder.Decode] turns back into v.
+//
+// Keys follow the decoder's tag name (falling back to the field name), `-` fields are skipped, `omitempty`
+// drops zero values and empty collections, squashed and `remain` fields are merged into their parent.
+// [Encodable] and encoding.TextMarshaler values are encoded by their own methods, time.Duration as a string.
+func (d *Decoder) Encode(v any) (map[string]any, error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil, errors.New("failed to encode: nil value")
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct && value.Kind() != reflect.Map {
+		return nil, fmt.Errorf("failed to encode: expected a struct or a map, got %T", v)
+	}
+	result, err := d.encodeValue(value)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to encode"), err)
+	}
+	out, ok := result.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("failed to encode: %T encoded itself as %T, not a map", v, result)
+	}
+	return out, nil
+}
+
+// Encode converts v into a map using the [Default] decoder, see [Decoder.Encode].
+func Encode(v any) (map[string]any, error) {
+	return Default().Encode(v)
+}
+
+func (d *Decoder) encodeValue(v reflect.Value) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+	default:
+	}
+	if result, ok, err := encodeSelf(v); ok {
+		if err != nil || result == nil || reflect.TypeOf(result) == v.Type() {
+			return result, err
+		}
+		return d.encodeValue(reflect.ValueOf(result))
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return d.encodeValue(v.Elem())
+	case reflect.Struct:
+		out := make(map[string]any)
+		if err := d.encodeStruct(v, out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	case reflect.Map:
+		return d.encodeMap(v)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return append([]byte(nil), v.Bytes()...), nil
+		}
+		out := make([]any, v.Len())
+		for i := range v.Len() {
+			item, err := d.encodeValue(v.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			out[i] = item
+		}
+		return out, nil
+	default:
+		return v.Interface(), nil
+	}
+}
+
+// encodeSelf encodes values implementing Encodable, encoding.TextMarshaler or being a time.Duration.
+// ok reports whether v was handled.
+func encodeSelf(v reflect.Value) (result any, ok bool, err error) {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String(), true, nil
+	}
+	if self, ok := implementing(v, encodableType); ok {
+		result, err := self.Interface().(Encodable).Encode()
+		return result, true, err
+	}
+	if self, ok := implementing(v, textMarshalerType); ok {
+		text, err := self.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), true, err
+	}
+	return nil, false, nil
+}
+
+// implementing returns v, or a pointer to (a copy of) v, if either implements iface.
+func implementing(v reflect.Value, iface reflect.Type) (reflect.Value, bool) {
+	if v.Kind() == reflect.Interface {
+		return v, false
+	}
+	if v.Type().Implements(iface) {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return v, false
+		}
+		return v, true
+	}
+	if v.Kind() == reflect.Pointer || !reflect.PointerTo(v.Type()).Implements(iface) {
+		return v, false
+	}
+	if v.CanAddr() {
+		return v.Addr(), true
+	}
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	return ptr, true
+}
+
+func (d *Decoder) encodeStruct(v reflect.Value, out map[string]any) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, opts := d.fieldTag(field)
+		if name == "-" {
+			continue
+		}
+		value := v.Field(i)
+		squash := opts["squash"] || (d.squashTagOption != "" && opts[d.squashTagOption]) ||
+			(d.squash && field.Anonymous)
+		// Unexported embedded structs still promote their fields when squashed.
+		if !field.IsExported() && !(squash && field.Anonymous) {
+			continue
+		}
+		if squash || opts["remain"] {
+			if err := d.encodeInto(value, out); err != nil {
+				return fmt.Errorf("%s: %w", field.Name, err)
+			}
+			continue
+		}
+		if opts["omitempty"] && isEmptyValue(value) {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+			if d.keyPolicy != nil {
+				name = d.keyPolicy(name)
+			}
+		}
+		encoded, err := d.encodeValue(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		out[name] = encoded
+	}
+	return nil
+}
+
+// encodeInto merges the encoded struct or map v into out.
+func (d *Decoder) encodeInto(v reflect.Value, out map[string]any) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return d.encodeStruct(v, out)
+	case reflect.Map:
+		encoded, err := d.encodeMap(v)
+		if err != nil {
+			return err
+		}
+		m, _ := encoded.(map[string]any)
+		for key, value := range m {
+			out[key] = value
+		}
+		return nil
+	default:
+		return fmt.Errorf("cannot squash %s", v.Type())
+	}
+}
+
+func (d *Decoder) encodeMap(v reflect.Value) (any, error) {
+	if v.IsNil() {
+		return nil, nil
+	}
+	out := make(map[string]any, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := d.encodeValue(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		keyStr := fmt.Sprint(key)
+		value, err := d.encodeValue(iter.Value())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", keyStr, err)
+		}
+		out[keyStr] = value
+	}
+	return out, nil
+}
+
+// fieldTag returns the name and options of the first non-empty tag among the decoder's tag names.
+func (d *Decoder) fieldTag(field reflect.StructField) (string, map[string]bool) {
+	for tagName := range strings.SplitSeq(d.tagName, ",") {
+		tag, ok := field.Tag.Lookup(strings.TrimSpace(tagName))
+		if !ok || tag == "" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		opts := make(map[string]bool, len(parts)-1)
+		for _, opt := range parts[1:] {
+			opts[strings.TrimSpace(opt)] = true
+		}
+		return parts[0], opts
+	}
+	return "", nil
+}
+
+// isEmptyValue reports whether v is a zero value or an empty collection, as dropped by `omitempty`.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
@@ -0,0 +1,97 @@
+package decoder_test
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/fmotalleb/go-tools/decoder"
+	"github.com/fmotalleb/go-tools/decoder/types"
+	"github.com/fmotalleb/go-tools/matcher"
+	"github.com/fmotalleb/go-tools/writer"
+)
+
+type encodeBase struct {
+	ID string `mapstructure:"id"`
+}
+
+type encodeUpstream struct {
+	Address netip.AddrPort `mapstructure:"address"`
+	Weight  int            `mapstructure:"weight,omitempty"`
+}
+
+type encodeConfig struct {
+	encodeBase `mapstructure:",squash"`
+	Name       string                    `mapstructure:"name"`
+	Timeout    time.Duration             `mapstructure:"timeout"`
+	MaxBody    types.ByteSize            `mapstructure:"max_body"`
+	Tags       []string                  `mapstructure:"tags,omitempty"`
+	Labels     map[string]string         `mapstructure:"labels,omitempty"`
+	Upstreams  []encodeUpstream          `mapstructure:"upstreams"`
+	Primary    *encodeUpstream           `mapstructure:"primary,omitempty"`
+	Routes     map[string]encodeUpstream `mapstructure:"routes"`
+	Hosts      matcher.Matcher           `mapstructure:"hosts"`
+	Output     writer.Writer             `mapstructure:"output"`
+	Secret     string                    `mapstructure:"-"`
+	Untagged   bool
+	Extra      map[string]any `mapstructure:",remain"`
+}
+
+func TestEncode_RoundTrip(t *testing.T) {
+	src := map[string]any{
+		"id":       "svc-1",
+		"name":     "api",
+		"timeout":  "1m30s",
+		"max_body": "10MB",
+		"upstreams": []any{
+			map[string]any{"address": "10.0.0.1:80", "weight": 2},
+			map[string]any{"address": "[::1]:8080"},
+		},
+		"routes":   map[string]any{"/": map[string]any{"address": "10.0.0.2:80"}},
+		"hosts":    "glob:*.example.com",
+		"output":   "rotate,/tmp/encode-test.log",
+		"untagged": true,
+		"custom":   "kept",
+	}
+	var cfg encodeConfig
+	assert.NoError(t, decoder.Decode(&cfg, src))
+
+	encoded, err := decoder.Encode(&cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"id":       "svc-1",
+		"name":     "api",
+		"timeout":  "1m30s",
+		"max_body": "10MB",
+		"upstreams": []any{
+			map[string]any{"address": "10.0.0.1:80", "weight": 2},
+			map[string]any{"address": "[::1]:8080"},
+		},
+		"routes":   map[string]any{"/": map[string]any{"address": "10.0.0.2:80"}},
+		"hosts":    "glob:*.example.com",
+		"output":   "rotate,/tmp/encode-test.log",
+		"Untagged": true,
+		"custom":   "kept",
+	}, encoded)
+
+	var back encodeConfig
+	assert.NoError(t, decoder.Decode(&back, encoded))
+	again, err := decoder.Encode(back)
+	assert.NoError(t, err)
+	assert.Equal(t, encoded, again)
+}
+
+func TestEncode_TagNames(t *testing.T) {
+	type item struct {
+		Name  string `yaml:"display_name" json:"name"`
+		Count int    `json:"count,omitempty"`
+	}
+	d := decoder.New(decoder.WithTagName("yaml,json"))
+	encoded, err := d.Encode(item{Name: "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"display_name": "x"}, encoded)
+
+	_, err = decoder.Encode("not a struct")
+	assert.Error(t, err)
+}
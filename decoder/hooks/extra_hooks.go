@@ -1,18 +1,71 @@
 package hooks
 
 import (
+	"sync"
+
 	"github.com/go-viper/mapstructure/v2"
 )
 
-var registeredHooks = []mapstructure.DecodeHookFunc{}
+// Registry is an ordered set of decode hooks, safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	hooks   []mapstructure.DecodeHookFunc
+	version uint64
+}
 
-func RegisterHook(hook mapstructure.DecodeHookFunc) {
+// NewRegistry creates a registry holding the given hooks.
+func NewRegistry(hooks ...mapstructure.DecodeHookFunc) *Registry {
+	r := &Registry{}
+	for _, hook := range hooks {
+		r.Register(hook)
+	}
+	return r
+}
+
+// Register appends hook to the registry, nil hooks are ignored.
+func (r *Registry) Register(hook mapstructure.DecodeHookFunc) {
 	if hook == nil {
 		return
 	}
-	registeredHooks = append(registeredHooks, hook)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, hook)
+	r.version++
+}
+
+// Hooks returns a snapshot of registered hooks.
+func (r *Registry) Hooks() []mapstructure.DecodeHookFunc {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]mapstructure.DecodeHookFunc(nil), r.hooks...)
+}
+
+// Version is incremented on every change, so callers can cache anything derived from [Registry.Hooks].
+func (r *Registry) Version() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.version
+}
+
+// Clone returns an independent copy of the registry.
+func (r *Registry) Clone() *Registry {
+	return NewRegistry(r.Hooks()...)
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default returns the process-wide registry used by [RegisterHook].
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// RegisterHook appends hook to the [Default] registry.
+func RegisterHook(hook mapstructure.DecodeHookFunc) {
+	defaultRegistry.Register(hook)
 }
 
+// GetExtraHooks returns the [NetHooks] followed by a snapshot of hooks in the [Default] registry,
+// as registered by this package before registries existed, for callers composing their own decoder.
 func GetExtraHooks() []mapstructure.DecodeHookFunc {
-	return registeredHooks
+	return append(NetHooks(), defaultRegistry.Hooks()...)
 }
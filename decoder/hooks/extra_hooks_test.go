@@ -0,0 +1,23 @@
+package hooks_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/fmotalleb/go-tools/decoder/hooks"
+	"github.com/go-viper/mapstructure/v2"
+)
+
+func TestGetExtraHooks_IncludesNetHooks(t *testing.T) {
+	var out struct {
+		Listen netip.AddrPort
+	}
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(hooks.GetExtraHooks()...),
+		Result:     &out,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, dec.Decode(map[string]any{"listen": ":8080"}))
+	assert.Equal(t, netip.MustParseAddrPort("0.0.0.0:8080"), out.Listen)
+}
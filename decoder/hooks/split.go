@@ -0,0 +1,163 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SplitOption configures how [Split] and [SplitKeyValues] tokenize strings.
+type SplitOption = func(*splitConfig)
+
+type splitConfig struct {
+	keepEmpty bool
+	noTrim    bool
+}
+
+// WithKeepEmpty keeps empty elements (e.g. the middle of `a,,b`) instead of dropping them.
+// A quoted empty element (`""`) is always kept.
+func WithKeepEmpty() SplitOption {
+	return func(c *splitConfig) {
+		c.keepEmpty = true
+	}
+}
+
+// WithoutTrim keeps whitespace around unquoted elements.
+func WithoutTrim() SplitOption {
+	return func(c *splitConfig) {
+		c.noTrim = true
+	}
+}
+
+// Split splits s on sep like a CSV record.
+//
+// Elements may be wrapped in double or single quotes to contain sep, a quote only opens at the start
+// of an element so `it's` stays plain. Inside double quotes a backslash escapes the next character,
+// elsewhere it is literal (`C:\dir`), single quotes are literal.
+// Whitespace around elements is trimmed and empty elements are dropped unless configured otherwise.
+func Split(s string, sep string, opts ...SplitOption) ([]string, error) {
+	cfg := newSplitConfig(opts)
+	raw, err := splitQuoted(s, sep, "", -1)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(raw))
+	for _, token := range raw {
+		value, empty := cfg.unquote(token)
+		if empty && !cfg.keepEmpty {
+			continue
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// SplitKeyValues splits s into `key<kvSep>value` pairs separated by sep, e.g. `team=core,tier=1`.
+// Keys and values follow the quoting rules of [Split]; a pair is split on its first unquoted kvSep.
+func SplitKeyValues(s string, sep string, kvSep string, opts ...SplitOption) (map[string]string, error) {
+	if kvSep == "" {
+		return nil, errors.New("empty key/value separator")
+	}
+	cfg := newSplitConfig(opts)
+	raw, err := splitQuoted(s, sep, kvSep, -1)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(raw))
+	for _, token := range raw {
+		if _, empty := cfg.unquote(token); empty {
+			continue
+		}
+		pair, err := splitQuoted(token, kvSep, "", 2)
+		if err != nil {
+			return nil, err
+		}
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("expected key%svalue pair, got '%s'", kvSep, strings.TrimSpace(token))
+		}
+		key, _ := cfg.unquote(pair[0])
+		value, _ := cfg.unquote(pair[1])
+		result[key] = value
+	}
+	return result, nil
+}
+
+func newSplitConfig(opts []SplitOption) *splitConfig {
+	cfg := new(splitConfig)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// splitQuoted splits s on sep outside quotes, returning at most n raw tokens (n < 0 means all).
+// As in CSV, a quote only opens at the start of an element, or of its value after the first kvSep
+// when kvSep is set, and a backslash only escapes inside double quotes, so `it's` and `C:\dir` are plain.
+// Tokens keep their quotes and escapes, see [splitConfig.unquote].
+func splitQuoted(s string, sep string, kvSep string, n int) ([]string, error) {
+	if sep == "" {
+		return nil, errors.New("empty separator")
+	}
+	var (
+		result     []string
+		quote      byte
+		start      int
+		fieldStart int
+		seenKV     bool
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && strings.TrimSpace(s[fieldStart:i]) == "":
+			quote = c
+		case strings.HasPrefix(s[i:], sep) && (n < 0 || len(result) < n-1):
+			result = append(result, s[start:i])
+			i += len(sep) - 1
+			start, fieldStart, seenKV = i+1, i+1, false
+		case kvSep != "" && !seenKV && strings.HasPrefix(s[i:], kvSep):
+			i += len(kvSep) - 1
+			fieldStart, seenKV = i+1, true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in '%s'", quote, s)
+	}
+	return append(result, s[start:]), nil
+}
+
+// unquote trims the raw token and, if it starts with a quote, removes the quotes and resolves
+// the escapes of double quotes; text after the closing quote is kept as is.
+// empty reports whether the token held nothing, not even a quoted empty string.
+func (c *splitConfig) unquote(token string) (value string, empty bool) {
+	if !c.noTrim {
+		token = strings.TrimSpace(token)
+	}
+	if token == "" {
+		return "", true
+	}
+	lead := len(token) - len(strings.TrimLeft(token, " \t\r\n"))
+	if lead == len(token) || (token[lead] != '"' && token[lead] != '\'') {
+		return token, false
+	}
+	quote := token[lead]
+	var sb strings.Builder
+	sb.Grow(len(token))
+	sb.WriteString(token[:lead])
+	i := lead + 1
+	for ; i < len(token) && token[i] != quote; i++ {
+		if token[i] == '\\' && quote == '"' && i+1 < len(token) {
+			i++
+		}
+		sb.WriteByte(token[i])
+	}
+	if i < len(token) {
+		sb.WriteString(token[i+1:])
+	}
+	return sb.String(), false
+}
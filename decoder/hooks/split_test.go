@@ -0,0 +1,78 @@
+package hooks_test
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/fmotalleb/go-tools/decoder/hooks"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  []hooks.SplitOption
+		want  []string
+	}{
+		{name: "plain", input: "a,b,c", want: []string{"a", "b", "c"}},
+		{name: "trim", input: " a , b ,c ", want: []string{"a", "b", "c"}},
+		{name: "double quotes", input: `"a,b",c`, want: []string{"a,b", "c"}},
+		{name: "single quotes", input: `'a,b',c`, want: []string{"a,b", "c"}},
+		{name: "quoted whitespace", input: `" a ",b`, want: []string{" a ", "b"}},
+		{name: "backslash outside quotes is literal", input: `a\,b,c`, want: []string{`a\`, "b", "c"}},
+		{name: "apostrophe", input: "it's,ok", want: []string{"it's", "ok"}},
+		{name: "apostrophes", input: "it's,they're", want: []string{"it's", "they're"}},
+		{name: "windows path", input: `C:\dir,D:\other\file.txt`, want: []string{`C:\dir`, `D:\other\file.txt`}},
+		{name: "quoted windows path", input: `'C:\my dir, old',x`, want: []string{`C:\my dir, old`, "x"}},
+		{name: "quote after text is literal", input: `a"b,c`, want: []string{`a"b`, "c"}},
+		{name: "escaped quote", input: `"say \"hi\"",x`, want: []string{`say "hi"`, "x"}},
+		{name: "single quotes are literal", input: `'a\b'`, want: []string{`a\b`}},
+		{name: "drop empty", input: "a,,b,", want: []string{"a", "b"}},
+		{name: "keep quoted empty", input: `a,"",b`, want: []string{"a", "", "b"}},
+		{name: "keep empty", input: "a,,b", opts: []hooks.SplitOption{hooks.WithKeepEmpty()}, want: []string{"a", "", "b"}},
+		{name: "no trim", input: "a, b", opts: []hooks.SplitOption{hooks.WithoutTrim()}, want: []string{"a", " b"}},
+		{name: "empty input", input: "", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hooks.Split(tt.input, ",", tt.opts...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	got, err := hooks.Split("a::b::'c::d'", "::")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c::d"}, got)
+
+	_, err = hooks.Split(`"a,b`, ",")
+	assert.Error(t, err)
+}
+
+func TestSplitKeyValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]string
+	}{
+		{name: "plain", input: "team=core,tier=1", want: map[string]string{"team": "core", "tier": "1"}},
+		{name: "value with separator", input: `team=core,desc="a, b"`, want: map[string]string{"team": "core", "desc": "a, b"}},
+		{name: "value with equals", input: "expr=a=b", want: map[string]string{"expr": "a=b"}},
+		{name: "quoted key", input: `"a=b"=c`, want: map[string]string{"a=b": "c"}},
+		{name: "empty value", input: "a=,b=2", want: map[string]string{"a": "", "b": "2"}},
+		{name: "trim and skip empty", input: " a = 1 ,, ", want: map[string]string{"a": "1"}},
+		{name: "apostrophe", input: "msg=it's,b=2", want: map[string]string{"msg": "it's", "b": "2"}},
+		{name: "windows path", input: `path=C:\dir,b=2`, want: map[string]string{"path": `C:\dir`, "b": "2"}},
+		{name: "single quoted value", input: `desc='a, b',c=d`, want: map[string]string{"desc": "a, b", "c": "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hooks.SplitKeyValues(tt.input, ",", "=")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := hooks.SplitKeyValues("team", ",", "=")
+	assert.Error(t, err)
+}
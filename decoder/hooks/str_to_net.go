@@ -3,61 +3,41 @@ package hooks
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net"
 	"net/netip"
 	"reflect"
-	"strings"
 
+	"github.com/fmotalleb/go-tools/decoder/types"
 	"github.com/go-viper/mapstructure/v2"
 )
 
-// init registers mapstructure decode hooks that convert configuration values into netip.AddrPort and net.IP types.
-// It registers StringToNetAddrPortHook, StringToNetAddrHook, IntToNetAddrPortHook, and StringToCIDRHook.
-func init() {
-	RegisterHook(StringToNetAddrPortHook())
-	RegisterHook(StringToNetAddrHook())
-	RegisterHook(IntToNetAddrPortHook())
-	RegisterHook(StringToCIDRHook())
+// NetHooks returns the hooks that convert configuration values into network types:
+// netip.AddrPort, netip.Addr, netip.Prefix, net.IP, net.IPNet and the network types of the types package.
+// They run before the slice splitting hook so a comma-separated types.PrefixList is parsed as a whole.
+func NetHooks() []mapstructure.DecodeHookFunc {
+	return []mapstructure.DecodeHookFunc{
+		StringToNetAddrPortHook(),
+		StringToNetAddrHook(),
+		StringToNetIPAddrHook(),
+		StringToPrefixHook(),
+		IntToNetAddrPortHook(),
+		StringToCIDRHook(),
+		StringToHostPortHook(),
+		StringToPortRangeHook(),
+		StringToNetAddrTypeHook(),
+	}
 }
 
 // StringToNetAddrPortHook returns a mapstructure.DecodeHookFunc that converts string values into netip.AddrPort.
 //
-// The hook accepts either "host:port" or "port". If only a port is provided, the host defaults to "127.0.0.1".
-// An empty string yields the zero netip.AddrPort. If the input is not a string, the hook returns an error;
-// if the string cannot be parsed as an address:port, the hook returns the parsing error.
+// The hook accepts "host:port", "[v6]:port", ":port" or "port". If only a port is provided, the host defaults
+// to "127.0.0.1"; ":port" binds to the unspecified address "0.0.0.0".
+// An empty string yields the zero netip.AddrPort. See types.ParseAddrPort.
 func StringToNetAddrPortHook() mapstructure.DecodeHookFunc {
-	return func(f reflect.Type, t reflect.Type, val interface{}) (interface{}, error) {
-		if f.Kind() != reflect.String {
-			return val, nil
-		}
-		if t != reflect.TypeOf(netip.AddrPort{}) {
-			return val, nil
-		}
-		if str, ok := val.(string); ok {
-			if str == "" {
-				return netip.AddrPort{}, nil
-			}
-			split := strings.Split(str, ":")
-			final := make([]string, 2)
-			switch len(split) {
-			case 1:
-				final[0] = ""
-				final[1] = split[0]
-			case 2:
-				final[0] = split[0]
-				final[1] = split[1]
-			}
-			if final[0] == "" {
-				final[0] = "127.0.0.1"
-			}
-			addrPort, err := netip.ParseAddrPort(final[0] + ":" + final[1])
-			if err != nil {
-				return nil, err
-			}
-			return addrPort, nil
-		}
-		return val, errors.New("expected string value for netip.AddrPort")
-	}
+	return stringHook(reflect.TypeFor[netip.AddrPort](), func(str string) (any, error) {
+		return types.ParseAddrPort(str)
+	})
 }
 
 // StringToNetAddrHook returns a mapstructure.DecodeHookFunc that converts string inputs into net.IP values.
@@ -85,34 +65,52 @@ func StringToNetAddrHook() mapstructure.DecodeHookFunc {
 	}
 }
 
-// IntToNetAddrPortHook produces a mapstructure.DecodeHookFunc that converts signed integer values into netip.AddrPort values.
-// IntToNetAddrPortHook returns a mapstructure.DecodeHookFunc that converts signed integers into netip.AddrPort values.
-// The hook treats the integer as a port on "127.0.0.1", parses "127.0.0.1:<port>" into a netip.AddrPort, and returns a parsing error if the port is invalid.
+// StringToNetIPAddrHook returns a mapstructure.DecodeHookFunc that converts strings into netip.Addr.
+// IPv6 addresses may be wrapped in brackets; an empty string yields the zero netip.Addr.
+func StringToNetIPAddrHook() mapstructure.DecodeHookFunc {
+	return stringHook(reflect.TypeFor[netip.Addr](), func(str string) (any, error) {
+		return types.ParseAddr(str)
+	})
+}
+
+// StringToPrefixHook returns a mapstructure.DecodeHookFunc that converts strings into netip.Prefix
+// and comma-separated strings into types.PrefixList.
+// A bare address is taken as a single host prefix; see types.ParsePrefix.
+func StringToPrefixHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		stringHook(reflect.TypeFor[netip.Prefix](), func(str string) (any, error) {
+			return types.ParsePrefix(str)
+		}),
+		stringHook(reflect.TypeFor[types.PrefixList](), func(str string) (any, error) {
+			return types.ParsePrefixList(str)
+		}),
+	)
+}
+
+// IntToNetAddrPortHook returns a mapstructure.DecodeHookFunc that converts integers into netip.AddrPort values.
+// The hook treats the integer as a port on "127.0.0.1" and returns an error if the port is out of range.
 func IntToNetAddrPortHook() mapstructure.DecodeHookFunc {
 	return func(f reflect.Type, t reflect.Type, val interface{}) (interface{}, error) {
-		switch f.Kind() {
-		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
-		default:
+		if t != reflect.TypeOf(netip.AddrPort{}) {
 			return val, nil
 		}
-		if t != reflect.TypeOf(netip.AddrPort{}) {
+		port, ok, err := intPort(f, val)
+		if !ok {
 			return val, nil
 		}
-		strVal := fmt.Sprintf("127.0.0.1:%d", val)
-		addrPort, err := netip.ParseAddrPort(strVal)
 		if err != nil {
 			return nil, err
 		}
-		return addrPort, nil
+		return netip.AddrPortFrom(types.DefaultHost, port), nil
 	}
 }
 
 // StringToCIDRHook provides a mapstructure.DecodeHookFunc that parses CIDR-formatted
-// strings into net.IP values.
+// strings into net.IPNet values.
 //
 // Empty string inputs produce a nil result. For valid CIDR strings the hook
-// returns the IP component from net.ParseCIDR. Invalid CIDR strings return the
-// parsing error. Non-string source values are returned unchanged.
+// returns the network component from net.ParseCIDR, so host bits are masked.
+// Invalid CIDR strings return the parsing error. Non-string source values are returned unchanged.
 func StringToCIDRHook() mapstructure.DecodeHookFunc {
 	return func(f reflect.Type, t reflect.Type, val interface{}) (interface{}, error) {
 		if f.Kind() != reflect.String {
@@ -134,3 +132,78 @@ func StringToCIDRHook() mapstructure.DecodeHookFunc {
 		return val, nil
 	}
 }
+
+// StringToHostPortHook returns a mapstructure.DecodeHookFunc that converts strings like `example.com:80`,
+// `[::1]:80` or `:80`, and integer ports, into types.HostPort.
+func StringToHostPortHook() mapstructure.DecodeHookFunc {
+	target := reflect.TypeFor[types.HostPort]()
+	return mapstructure.ComposeDecodeHookFunc(
+		stringHook(target, func(str string) (any, error) {
+			return types.ParseHostPort(str)
+		}),
+		intHook(target, func(port uint16) any {
+			return types.HostPort{Port: port}
+		}),
+	)
+}
+
+// StringToPortRangeHook returns a mapstructure.DecodeHookFunc that converts strings like `8000-8100`,
+// and single integer ports, into types.PortRange.
+func StringToPortRangeHook() mapstructure.DecodeHookFunc {
+	target := reflect.TypeFor[types.PortRange]()
+	return mapstructure.ComposeDecodeHookFunc(
+		stringHook(target, func(str string) (any, error) {
+			return types.ParsePortRange(str)
+		}),
+		intHook(target, func(port uint16) any {
+			return types.PortRange{Start: port, End: port}
+		}),
+	)
+}
+
+// StringToNetAddrTypeHook returns a mapstructure.DecodeHookFunc that converts strings like
+// `unix:/run/app.sock`, `udp://host:53` or `host:port` into types.NetAddr.
+func StringToNetAddrTypeHook() mapstructure.DecodeHookFunc {
+	return stringHook(reflect.TypeFor[types.NetAddr](), func(str string) (any, error) {
+		return types.ParseNetAddr(str)
+	})
+}
+
+// intHook builds a hook that converts integer ports into target using build.
+func intHook(target reflect.Type, build func(port uint16) any) mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, val interface{}) (interface{}, error) {
+		if t != target {
+			return val, nil
+		}
+		port, ok, err := intPort(f, val)
+		if !ok {
+			return val, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return build(port), nil
+	}
+}
+
+// intPort extracts a port from signed or unsigned integer values.
+// ok reports whether val is an integer at all.
+func intPort(f reflect.Type, val interface{}) (port uint16, ok bool, err error) {
+	v := reflect.ValueOf(val)
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := v.Int()
+		if n < 0 || n > math.MaxUint16 {
+			return 0, true, fmt.Errorf("invalid port '%d': out of range", n)
+		}
+		return uint16(n), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n := v.Uint()
+		if n > math.MaxUint16 {
+			return 0, true, fmt.Errorf("invalid port '%d': out of range", n)
+		}
+		return uint16(n), true, nil
+	default:
+		return 0, false, nil
+	}
+}
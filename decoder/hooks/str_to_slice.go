@@ -2,18 +2,33 @@ package hooks
 
 import (
 	"reflect"
-	"strings"
 
 	"github.com/go-viper/mapstructure/v2"
 )
 
-// stringToSliceHookFunc converts strings to slices.
-func StringToSliceHookFunc(sep string) mapstructure.DecodeHookFunc {
+// StringToSliceHookFunc converts strings to slices, splitting them on sep with the quoting rules of [Split].
+// Each element is then decoded into the slice element type by the rest of the hook chain.
+// Byte slices are left to mapstructure.
+func StringToSliceHookFunc(sep string, opts ...SplitOption) mapstructure.DecodeHookFunc {
 	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
 		if from.Kind() != reflect.String || to.Kind() != reflect.Slice {
 			return data, nil
 		}
-		slice := strings.Split(data.(string), sep)
-		return slice, nil
+		if to.Elem().Kind() == reflect.Uint8 {
+			return data, nil
+		}
+		return Split(reflect.ValueOf(data).String(), sep, opts...)
+	}
+}
+
+// StringToMapHookFunc converts strings like `team=core,tier=1` into maps, splitting pairs on sep
+// and keys from values on kvSep with the quoting rules of [Split].
+// Each key and value is then decoded into the map key/element type by the rest of the hook chain.
+func StringToMapHookFunc(sep string, kvSep string, opts ...SplitOption) mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to.Kind() != reflect.Map {
+			return data, nil
+		}
+		return SplitKeyValues(reflect.ValueOf(data).String(), sep, kvSep, opts...)
 	}
 }
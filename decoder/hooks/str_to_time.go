@@ -0,0 +1,83 @@
+package hooks
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/fmotalleb/go-tools/decoder/types"
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// TimeHooks returns the hooks that convert configuration values into time.Time, *time.Location,
+// time.Weekday and types.TimeOfDay. They must run before [LooseTypeCaster], which would otherwise
+// try to cast weekday names into integers.
+func TimeHooks(layouts ...string) []mapstructure.DecodeHookFunc {
+	return []mapstructure.DecodeHookFunc{
+		StringToTimeHookFunc(layouts...),
+		IntToTimeHook(),
+		StringToLocationHook(),
+		StringToWeekdayHook(),
+		StringToTimeOfDayHook(),
+	}
+}
+
+// StringToTimeHookFunc returns a mapstructure.DecodeHookFunc that converts strings into time.Time.
+//
+// Relative expressions (`now-1h`) are accepted, otherwise each layout is tried in order and the first
+// successful parse wins, then unix epochs in seconds or milliseconds. If no layout is given,
+// types.DefaultTimeLayouts is used. An empty string yields the zero time.Time.
+func StringToTimeHookFunc(layouts ...string) mapstructure.DecodeHookFunc {
+	return stringHook(reflect.TypeFor[time.Time](), func(str string) (any, error) {
+		return types.ParseTime(str, layouts...)
+	})
+}
+
+// IntToTimeHook returns a mapstructure.DecodeHookFunc that converts integers into time.Time as unix epochs,
+// values of 1e12 and above are taken as milliseconds.
+func IntToTimeHook() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, val interface{}) (interface{}, error) {
+		if t != reflect.TypeFor[time.Time]() {
+			return val, nil
+		}
+		v := reflect.ValueOf(val)
+		switch f.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return types.EpochToTime(v.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return types.EpochToTime(int64(v.Uint())), nil
+		case reflect.Float32, reflect.Float64:
+			return types.EpochToTime(int64(v.Float())), nil
+		default:
+			return val, nil
+		}
+	}
+}
+
+// StringToLocationHook returns a mapstructure.DecodeHookFunc that converts IANA names like `Europe/Berlin`,
+// `UTC` or `Local` into *time.Location. An empty string yields a nil location.
+func StringToLocationHook() mapstructure.DecodeHookFunc {
+	return stringHook(reflect.TypeFor[*time.Location](), func(str string) (any, error) {
+		loc, err := time.LoadLocation(str)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse input '%s' into time.Location: %w", str, err)
+		}
+		return loc, nil
+	})
+}
+
+// StringToWeekdayHook returns a mapstructure.DecodeHookFunc that converts names like `monday` or `Mon`
+// into time.Weekday.
+func StringToWeekdayHook() mapstructure.DecodeHookFunc {
+	return stringHook(reflect.TypeFor[time.Weekday](), func(str string) (any, error) {
+		return types.ParseWeekday(str)
+	})
+}
+
+// StringToTimeOfDayHook returns a mapstructure.DecodeHookFunc that converts clock times like `09:30`
+// into types.TimeOfDay.
+func StringToTimeOfDayHook() mapstructure.DecodeHookFunc {
+	return stringHook(reflect.TypeFor[types.TimeOfDay](), func(str string) (any, error) {
+		return types.ParseTimeOfDay(str)
+	})
+}
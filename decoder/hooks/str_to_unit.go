@@ -0,0 +1,94 @@
+package hooks
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"time"
+
+	"github.com/fmotalleb/go-tools/decoder/types"
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// UnitHooks returns the hooks that convert human-entered quantities into sizes, durations,
+// percentages and file modes. They must run before [LooseTypeCaster], which would otherwise
+// try to cast strings like `10MB` into the underlying numeric kind.
+func UnitHooks() []mapstructure.DecodeHookFunc {
+	return []mapstructure.DecodeHookFunc{
+		StringToByteSizeHook(),
+		StringToDurationHook(),
+		StringToPercentHook(),
+		StringToFileModeHook(),
+	}
+}
+
+// StringToByteSizeHook returns a mapstructure.DecodeHookFunc that converts strings like `10MB`,
+// `512KiB` or `1.5G` into types.ByteSize.
+func StringToByteSizeHook() mapstructure.DecodeHookFunc {
+	return stringHook(reflect.TypeFor[types.ByteSize](), func(str string) (any, error) {
+		return types.ParseByteSize(str)
+	})
+}
+
+// StringToDurationHook returns a mapstructure.DecodeHookFunc that converts strings into
+// time.Duration and types.Duration, accepting `d`, `w` and `y` units on top of time.ParseDuration.
+func StringToDurationHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		stringHook(reflect.TypeFor[time.Duration](), func(str string) (any, error) {
+			return types.ParseDuration(str)
+		}),
+		stringHook(reflect.TypeFor[types.Duration](), func(str string) (any, error) {
+			d, err := types.ParseDuration(str)
+			return types.Duration(d), err
+		}),
+	)
+}
+
+// StringToPercentHook returns a mapstructure.DecodeHookFunc that converts strings like `75%`
+// into a types.Percent ratio (0.75).
+func StringToPercentHook() mapstructure.DecodeHookFunc {
+	return stringHook(reflect.TypeFor[types.Percent](), func(str string) (any, error) {
+		p, err := types.ParsePercent(str)
+		return types.Percent(p), err
+	})
+}
+
+// StringToFileModeHook returns a mapstructure.DecodeHookFunc that converts octal strings like
+// `0644` into fs.FileMode and types.FileMode.
+func StringToFileModeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		stringHook(reflect.TypeFor[fs.FileMode](), func(str string) (any, error) {
+			return types.ParseFileMode(str)
+		}),
+		stringHook(reflect.TypeFor[types.FileMode](), func(str string) (any, error) {
+			m, err := types.ParseFileMode(str)
+			return types.FileMode(m), err
+		}),
+	)
+}
+
+// stringHook builds a hook that parses string inputs into target.
+// Empty strings yield the zero value of target; other source kinds are returned unchanged.
+func stringHook(target reflect.Type, parse func(string) (any, error)) mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, val interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String {
+			return val, nil
+		}
+		if t != target {
+			return val, nil
+		}
+		str, ok := val.(string)
+		if !ok {
+			if v := reflect.ValueOf(val); v.Kind() == reflect.String {
+				str, ok = v.String(), true
+			}
+		}
+		if !ok {
+			return val, errors.New("expected string value for " + target.String())
+		}
+		if str == "" {
+			return reflect.Zero(target).Interface(), nil
+		}
+		return parse(str)
+	}
+}
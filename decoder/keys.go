@@ -0,0 +1,311 @@
+package decoder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/fmotalleb/go-tools/log"
+	"go.uber.org/zap"
+)
+
+// KeyPolicy converts a key into its canonical form, a map key matches a field when both convert to the same form.
+// [KebabCase], [SnakeCase] and [CamelCase] make `max-size`, `MAX_SIZE`, `max_size` and `maxSize` all match
+// a `MaxSize` field or a `max-size` tag.
+type KeyPolicy = func(key string) string
+
+// KebabCase converts key into `kebab-case`.
+func KebabCase(key string) string {
+	return strings.Join(keyWords(key), "-")
+}
+
+// SnakeCase converts key into `snake_case`.
+func SnakeCase(key string) string {
+	return strings.Join(keyWords(key), "_")
+}
+
+// CamelCase converts key into `camelCase`.
+func CamelCase(key string) string {
+	words := keyWords(key)
+	for i := 1; i < len(words); i++ {
+		runes := []rune(words[i])
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, "")
+}
+
+// keyWords splits key into lower-cased words on `-`, `_`, `.`, spaces and camel case boundaries,
+// keeping acronyms together (`HTTPServer` is `http`, `server`).
+func keyWords(key string) []string {
+	runes := []rune(key)
+	words := make([]string, 0)
+	start := -1
+	flush := func(end int) {
+		if start >= 0 && end > start {
+			words = append(words, strings.ToLower(string(runes[start:end])))
+		}
+		start = -1
+	}
+	for i, r := range runes {
+		if r == '-' || r == '_' || r == '.' || unicode.IsSpace(r) {
+			flush(i)
+			continue
+		}
+		if start >= 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				flush(i)
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	flush(len(runes))
+	return words
+}
+
+// matchKey reports whether the map key names the field, per the decoder's key policy.
+func (d *Decoder) matchKey(mapKey, fieldName string) bool {
+	if d.keyPolicy == nil {
+		return strings.EqualFold(mapKey, fieldName)
+	}
+	return d.keyPolicy(mapKey) == d.keyPolicy(fieldName)
+}
+
+// keyTagged caches, per type, whether a type holds fields with `alias` or `deprecated` tags at any depth.
+var keyTagged sync.Map // reflect.Type -> bool
+
+func hasKeyTags(t reflect.Type) bool {
+	if cached, ok := keyTagged.Load(t); ok {
+		return cached.(bool)
+	}
+	result := scanKeyTags(t, make(map[reflect.Type]bool))
+	keyTagged.Store(t, result)
+	return result
+}
+
+func scanKeyTags(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return scanKeyTags(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if _, ok := f.Tag.Lookup("alias"); ok {
+				return true
+			}
+			if _, ok := f.Tag.Lookup("deprecated"); ok {
+				return true
+			}
+			if scanKeyTags(f.Type, visiting) {
+				return true
+			}
+		}
+	default:
+	}
+	return false
+}
+
+// resolveKeys rewrites src for decoding into t: values under `alias` keys are moved to their field's key
+// and the use of aliases and `deprecated` fields is logged as a warning with the key path.
+// With a key policy, keys of one map matching the same field are reported as an error.
+// src is returned untouched when t has no such tags and there is no key policy.
+func (d *Decoder) resolveKeys(ctx context.Context, t reflect.Type, src any, path string) (any, error) {
+	if src == nil || (d.keyPolicy == nil && !hasKeyTags(t)) {
+		return src, nil
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	value := reflect.ValueOf(src)
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := stringMap(value)
+		if !ok {
+			return src, nil
+		}
+		return m, errors.Join(d.resolveStruct(ctx, t, m, path)...)
+	case reflect.Slice, reflect.Array:
+		if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+			return src, nil
+		}
+		out := make([]any, value.Len())
+		errs := make([]error, 0)
+		for i := range value.Len() {
+			var err error
+			out[i], err = d.resolveKeys(ctx, t.Elem(), value.Index(i).Interface(), fmt.Sprintf("%s[%d]", path, i))
+			errs = append(errs, err)
+		}
+		return out, errors.Join(errs...)
+	case reflect.Map:
+		m, ok := stringMap(value)
+		if !ok {
+			return src, nil
+		}
+		errs := make([]error, 0)
+		for key, item := range m {
+			var err error
+			m[key], err = d.resolveKeys(ctx, t.Elem(), item, joinKeyPath(path, key))
+			errs = append(errs, err)
+		}
+		return m, errors.Join(errs...)
+	default:
+		return src, nil
+	}
+}
+
+func (d *Decoder) resolveStruct(ctx context.Context, t reflect.Type, m map[string]any, path string) []error {
+	errs := make([]error, 0)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, opts := d.fieldTag(field)
+		if name == "-" {
+			continue
+		}
+		squash := opts["squash"] || (d.squashTagOption != "" && opts[d.squashTagOption]) ||
+			(d.squash && field.Anonymous)
+		if squash {
+			fieldType := field.Type
+			for fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				errs = append(errs, d.resolveStruct(ctx, fieldType, m, path)...)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		key, found, err := d.findKey(m, name, path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if aliases, ok := field.Tag.Lookup("alias"); ok {
+			key, found, err = d.resolveAliases(ctx, m, name, key, found, aliases, path)
+			errs = append(errs, err)
+		}
+		if !found {
+			continue
+		}
+		if message, ok := field.Tag.Lookup("deprecated"); ok {
+			log.FromContext(ctx).Warn("deprecated config key",
+				zap.String("key", joinKeyPath(path, key)),
+				zap.String("message", message),
+			)
+		}
+		m[key], err = d.resolveKeys(ctx, field.Type, m[key], joinKeyPath(path, key))
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// resolveAliases moves the value of the first alias present in m to name, unless the field's own key is set.
+func (d *Decoder) resolveAliases(
+	ctx context.Context,
+	m map[string]any,
+	name, key string,
+	found bool,
+	aliases string,
+	path string,
+) (string, bool, error) {
+	logger := log.FromContext(ctx)
+	for alias := range strings.SplitSeq(aliases, ",") {
+		aliasKey, ok, err := d.findKey(m, strings.TrimSpace(alias), path)
+		if err != nil {
+			return key, found, err
+		}
+		if !ok || (found && aliasKey == key) {
+			continue
+		}
+		if found {
+			logger.Warn("deprecated config key ignored, its replacement is set",
+				zap.String("key", joinKeyPath(path, aliasKey)),
+				zap.String("replacement", joinKeyPath(path, key)),
+			)
+			delete(m, aliasKey)
+			continue
+		}
+		logger.Warn("deprecated config key",
+			zap.String("key", joinKeyPath(path, aliasKey)),
+			zap.String("replacement", joinKeyPath(path, name)),
+		)
+		m[name] = m[aliasKey]
+		delete(m, aliasKey)
+		key, found = name, true
+	}
+	return key, found, nil
+}
+
+// findKey returns the key of m matching name. With a key policy, several keys matching name,
+// e.g. `max-size` and `max_size`, are ambiguous and reported as an error naming them.
+func (d *Decoder) findKey(m map[string]any, name string, path string) (string, bool, error) {
+	if d.keyPolicy == nil {
+		if _, ok := m[name]; ok {
+			return name, true, nil
+		}
+		for key := range m {
+			if d.matchKey(key, name) {
+				return key, true, nil
+			}
+		}
+		return "", false, nil
+	}
+	matches := make([]string, 0, 1)
+	for key := range m {
+		if d.matchKey(key, name) {
+			matches = append(matches, key)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", false, nil
+	case 1:
+		return matches[0], true, nil
+	default:
+		slices.Sort(matches)
+		quoted := make([]string, len(matches))
+		for i, key := range matches {
+			quoted[i] = strconv.Quote(joinKeyPath(path, key))
+		}
+		return "", false, fmt.Errorf("keys %s all match %q", strings.Join(quoted, ", "), name)
+	}
+}
+
+// stringMap copies a map with string keys into a map[string]any.
+func stringMap(value reflect.Value) (map[string]any, bool) {
+	if value.Kind() != reflect.Map {
+		return nil, false
+	}
+	out := make(map[string]any, value.Len())
+	iter := value.MapRange()
+	for iter.Next() {
+		key := reflect.ValueOf(iter.Key().Interface())
+		if key.Kind() != reflect.String {
+			return nil, false
+		}
+		out[key.String()] = iter.Value().Interface()
+	}
+	return out, true
+}
+
+func joinKeyPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,117 @@
+package decoder_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/fmotalleb/go-tools/decoder"
+	"github.com/fmotalleb/go-tools/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestKeyPolicies(t *testing.T) {
+	cases := map[string][3]string{
+		"MaxSize":    {"max-size", "max_size", "maxSize"},
+		"max_size":   {"max-size", "max_size", "maxSize"},
+		"HTTPServer": {"http-server", "http_server", "httpServer"},
+		"tls.ca-v2":  {"tls-ca-v2", "tls_ca_v2", "tlsCaV2"},
+	}
+	for in, want := range cases {
+		assert.Equal(t, want[0], decoder.KebabCase(in), in)
+		assert.Equal(t, want[1], decoder.SnakeCase(in), in)
+		assert.Equal(t, want[2], decoder.CamelCase(in), in)
+	}
+}
+
+type keyed struct {
+	MaxSize int
+	Listen  string `mapstructure:"listen-addr" alias:"bind,address"`
+	Workers int    `mapstructure:"workers" deprecated:"scaled automatically"`
+	Nested  []struct {
+		Name string `mapstructure:"name" alias:"title"`
+	} `mapstructure:"nested"`
+}
+
+func TestWithKeyPolicy(t *testing.T) {
+	d := decoder.New(decoder.WithKeyPolicy(decoder.KebabCase))
+	var out keyed
+	err := d.Decode(&out, map[string]any{"max_size": 10, "listenAddr": ":80"})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, out.MaxSize)
+	assert.Equal(t, ":80", out.Listen)
+
+	encoded, err := d.Encode(out)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, encoded["max-size"].(int))
+	err = d.Decode(&out, map[string]any{"max-size": 1, "max_size": 2})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `keys "max-size", "max_size" all match "MaxSize"`)
+
+	err = d.Decode(&out, map[string]any{"nested": []any{map[string]any{"name": "a", "Name": "b"}}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `keys "nested[0].Name", "nested[0].name" all match "name"`)
+}
+
+func TestDecodeContext_AliasAndDeprecated(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	ctx := log.WithLogger(context.Background(), zap.New(core))
+
+	var out keyed
+	err := decoder.DecodeContext(ctx, &out, map[string]any{
+		"bind":    ":80",
+		"workers": 4,
+		"nested":  []any{map[string]any{"title": "a"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ":80", out.Listen)
+	assert.Equal(t, 4, out.Workers)
+	assert.Equal(t, "a", out.Nested[0].Name)
+
+	keys := make([]string, 0)
+	for _, entry := range logs.All() {
+		keys = append(keys, entry.ContextMap()["key"].(string))
+	}
+	assert.Equal(t, []string{"bind", "workers", "nested[0].title"}, keys)
+}
+
+func TestDecodeContext_PrimaryKeyWins(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	ctx := log.WithLogger(context.Background(), zap.New(core))
+
+	var out keyed
+	err := decoder.DecodeContext(ctx, &out, map[string]any{"address": ":1", "listen-addr": ":2"})
+	assert.NoError(t, err)
+	assert.Equal(t, ":2", out.Listen)
+	assert.Equal(t, 1, logs.Len())
+}
+
+type channel interface {
+	Room() string
+}
+
+type chatChannel struct {
+	Name string `mapstructure:"name" alias:"room"`
+}
+
+func (c chatChannel) Room() string { return c.Name }
+
+func TestDecodeContext_VariantKeys(t *testing.T) {
+	decoder.RegisterVariant("chat", func(opts chatChannel) (channel, error) {
+		return opts, nil
+	})
+	core, logs := observer.New(zap.WarnLevel)
+	ctx := log.WithLogger(context.Background(), zap.New(core))
+
+	var out struct {
+		Channel channel `mapstructure:"channel"`
+	}
+	err := decoder.DecodeContext(ctx, &out, map[string]any{
+		"channel": map[string]any{"type": "chat", "room": "ops"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ops", out.Channel.Room())
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, "room", logs.All()[0].ContextMap()["key"].(string))
+}
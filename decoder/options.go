@@ -0,0 +1,136 @@
+package decoder
+
+import (
+	"github.com/fmotalleb/go-tools/decoder/hooks"
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// Option configures a [Decoder] created by [New].
+type Option = func(*Decoder)
+
+// WithTagName sets the struct tag used to map keys to fields (Default `mapstructure`).
+// A comma-separated list (e.g. "yaml,json") is accepted, the first non-empty tag wins.
+func WithTagName(name string) Option {
+	return func(d *Decoder) {
+		d.tagName = name
+	}
+}
+
+// WithWeaklyTypedInput enables/disables mapstructure's weak type conversions (Default true).
+func WithWeaklyTypedInput(weak bool) Option {
+	return func(d *Decoder) {
+		d.weaklyTyped = weak
+	}
+}
+
+// WithoutWeakTyping disables mapstructure's weak type conversions.
+func WithoutWeakTyping() Option {
+	return WithWeaklyTypedInput(false)
+}
+
+// WithEnvSubst enables/disables env substitution on string inputs (Default true).
+func WithEnvSubst(enabled bool) Option {
+	return func(d *Decoder) {
+		d.envSubst = enabled
+	}
+}
+
+// WithoutEnvSubst disables env substitution on string inputs.
+func WithoutEnvSubst() Option {
+	return WithEnvSubst(false)
+}
+
+// WithSliceSeparator sets the separator used to split strings into slices (Default `,`).
+// An empty separator disables string to slice splitting.
+func WithSliceSeparator(sep string) Option {
+	return func(d *Decoder) {
+		d.sliceSep = sep
+	}
+}
+
+// WithKeyValueSeparator sets the separator between keys and values when decoding strings like
+// `team=core,tier=1` into maps (Default `=`). Pairs are split using the slice separator.
+// An empty separator disables string to map decoding.
+func WithKeyValueSeparator(sep string) Option {
+	return func(d *Decoder) {
+		d.kvSep = sep
+	}
+}
+
+// WithSplitOptions configures how strings are split into slices and maps, see [hooks.Split].
+func WithSplitOptions(opts ...hooks.SplitOption) Option {
+	return func(d *Decoder) {
+		d.splitOpts = append(d.splitOpts, opts...)
+	}
+}
+
+// WithTimeLayouts adds layouts accepted when decoding strings into time.Time.
+// They are tried in order after types.DefaultTimeLayouts.
+func WithTimeLayouts(layouts ...string) Option {
+	return func(d *Decoder) {
+		d.timeLayouts = append(d.timeLayouts, layouts...)
+	}
+}
+
+// WithSquash enables/disables squashing of every embedded struct (Default false).
+// Fields tagged with the squash option are squashed regardless.
+func WithSquash(squash bool) Option {
+	return func(d *Decoder) {
+		d.squash = squash
+	}
+}
+
+// WithSquashTagOption sets the tag option that marks an embedded struct for squashing (Default `squash`).
+func WithSquashTagOption(option string) Option {
+	return func(d *Decoder) {
+		d.squashTagOption = option
+	}
+}
+
+// WithDecodeNil enables/disables running hooks on nil inputs (Default true).
+func WithDecodeNil(decodeNil bool) Option {
+	return func(d *Decoder) {
+		d.decodeNil = decodeNil
+	}
+}
+
+// WithPrependHooks adds hooks that run before the default hook chain.
+func WithPrependHooks(hooks ...mapstructure.DecodeHookFunc) Option {
+	return func(d *Decoder) {
+		d.prependHooks = append(d.prependHooks, hooks...)
+	}
+}
+
+// WithHooks adds hooks that run after the default hook chain.
+func WithHooks(hooks ...mapstructure.DecodeHookFunc) Option {
+	return func(d *Decoder) {
+		d.appendHooks = append(d.appendHooks, hooks...)
+	}
+}
+
+// WithoutDefaultHooks drops the default hook chain (env substitution included), leaving
+// registry hooks and hooks added by [WithPrependHooks] and [WithHooks].
+func WithoutDefaultHooks() Option {
+	return func(d *Decoder) {
+		d.noDefaultHooks = true
+	}
+}
+
+// WithRegistry sets the registry providing extra hooks, decodables and parsers (Default [DefaultRegistry]).
+func WithRegistry(registry *Registry) Option {
+	return func(d *Decoder) {
+		if registry != nil {
+			d.registry = registry
+		}
+	}
+}
+
+// WithKeyPolicy matches map keys to fields by their canonical form under policy, e.g. with [KebabCase]
+// `max_size`, `maxSize` and `max-size` all decode into a `MaxSize` field (Default case-insensitive match).
+// A map holding several keys for one field, such as `max_size` and `max-size`, fails to decode.
+// [Decoder.Encode] writes untagged field names in the policy's form.
+func WithKeyPolicy(policy KeyPolicy) Option {
+	return func(d *Decoder) {
+		d.keyPolicy = policy
+	}
+}
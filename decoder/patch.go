@@ -0,0 +1,351 @@
+package decoder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// SliceMode selects how [Decoder.Patch] applies a slice value onto an existing slice.
+type SliceMode int
+
+const (
+	// SliceReplace replaces the existing slice (Default).
+	SliceReplace SliceMode = iota
+	// SliceAppend appends the decoded elements to the existing slice.
+	SliceAppend
+)
+
+// PatchOption alters a single [Decoder.Patch] call.
+type PatchOption = func(*patcher)
+
+// WithSliceMode sets how slices are patched (Default [SliceReplace]).
+// A field tagged `patch:"append"` or `patch:"replace"` overrides it.
+func WithSliceMode(mode SliceMode) PatchOption {
+	return func(p *patcher) {
+		p.sliceMode = mode
+	}
+}
+
+// WithPatchContext sets the context whose logger reports `alias` and `deprecated` keys (Default context.Background).
+func WithPatchContext(ctx context.Context) PatchOption {
+	return func(p *patcher) {
+		p.ctx = ctx
+	}
+}
+
+type patcher struct {
+	d         *Decoder
+	ctx       context.Context
+	sliceMode SliceMode
+	// mergePatch follows RFC 7386, where arrays always replace regardless of `patch` tags.
+	mergePatch bool
+}
+
+// Patch applies src onto the value dst points to, leaving everything src does not mention untouched:
+//   - absent keys keep their current value,
+//   - an explicit nil (`null`) resets the field to its zero value, or deletes the key of a map,
+//   - maps given for structs, struct pointers, maps and interfaces are merged key by key, an interface
+//     not holding a map or struct gets a map[string]any,
+//   - slices are replaced or appended to, see [WithSliceMode],
+//   - keys matching no field are merged into the `,remain` map field, if the struct has one,
+//   - any other value is decoded as [Decoder.Decode] would and replaces the field.
+//
+// Types decoding themselves ([Decodable], [Parsable], json.Unmarshaler) are always replaced as a whole.
+// dst is only changed when the whole patch applies, maps and struct pointers it holds are copied, not modified.
+func (d *Decoder) Patch(dst any, src any, opts ...PatchOption) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return errors.Join(
+			errors.New("failed to patch"),
+			errors.New("destination must be a non-nil pointer"),
+		)
+	}
+	p := &patcher{d: d, ctx: context.Background()}
+	for _, opt := range opts {
+		opt(p)
+	}
+	src, err := d.resolveKeys(p.ctx, v.Type().Elem(), src, "")
+	if err != nil {
+		return errors.Join(errors.New("failed to patch"), err)
+	}
+	// Patch a copy, so a failing patch leaves dst as it was; maps and pointers are copied on write.
+	patched := reflect.New(v.Elem().Type()).Elem()
+	patched.Set(v.Elem())
+	if err := p.patch(patched, src, "", p.sliceMode); err != nil {
+		return errors.Join(errors.New("failed to patch"), err)
+	}
+	v.Elem().Set(patched)
+	return nil
+}
+
+// MergePatch applies an RFC 7386 JSON Merge Patch document onto the value dst points to.
+// Objects are merged recursively, `null` removes a member and every other value, arrays included,
+// replaces the target. A document that is not an object replaces dst as a whole.
+func (d *Decoder) MergePatch(dst any, doc []byte, opts ...PatchOption) error {
+	var patch any
+	if err := json.Unmarshal(doc, &patch); err != nil {
+		return errors.Join(errors.New("failed to parse merge patch"), err)
+	}
+	opts = append(opts, func(p *patcher) {
+		p.sliceMode = SliceReplace
+		p.mergePatch = true
+	})
+	return d.Patch(dst, patch, opts...)
+}
+
+// Patch applies src onto dst using the [Default] decoder, see [Decoder.Patch].
+func Patch(dst any, src any, opts ...PatchOption) error {
+	return Default().Patch(dst, src, opts...)
+}
+
+// MergePatch applies an RFC 7386 JSON Merge Patch onto dst using the [Default] decoder, see [Decoder.MergePatch].
+func MergePatch(dst any, doc []byte, opts ...PatchOption) error {
+	return Default().MergePatch(dst, doc, opts...)
+}
+
+func (p *patcher) patch(target reflect.Value, src any, path string, sliceMode SliceMode) error {
+	if src == nil {
+		target.SetZero()
+		return nil
+	}
+	if !p.mergeable(target.Type()) {
+		return p.replace(target, src, path)
+	}
+	switch target.Kind() {
+	case reflect.Pointer:
+		if target.Type().Elem().Kind() != reflect.Struct {
+			break
+		}
+		m, ok := stringMap(reflect.ValueOf(src))
+		if !ok {
+			break
+		}
+		patched := reflect.New(target.Type().Elem())
+		if !target.IsNil() {
+			patched.Elem().Set(target.Elem())
+		}
+		if err := p.patchStruct(patched.Elem(), m, path); err != nil {
+			return err
+		}
+		target.Set(patched)
+		return nil
+	case reflect.Struct:
+		if m, ok := stringMap(reflect.ValueOf(src)); ok {
+			return p.patchStruct(target, m, path)
+		}
+	case reflect.Map:
+		if m, ok := stringMap(reflect.ValueOf(src)); ok {
+			return p.patchMap(target, m, path)
+		}
+	case reflect.Slice:
+		if sliceMode == SliceAppend && !target.IsNil() {
+			return p.appendSlice(target, src, path)
+		}
+	case reflect.Interface:
+		if m, ok := stringMap(reflect.ValueOf(src)); ok {
+			return p.patchInterface(target, m, path, sliceMode)
+		}
+	default:
+	}
+	return p.replace(target, src, path)
+}
+
+// patchInterface merges m into the map or struct an interface holds, any other value is replaced
+// by a map[string]any built from m, so `null` members are dropped at every depth.
+func (p *patcher) patchInterface(target reflect.Value, m map[string]any, path string, sliceMode SliceMode) error {
+	if !target.IsNil() {
+		current := target.Elem()
+		kind := current.Kind()
+		if kind == reflect.Pointer && current.Type().Elem().Kind() == reflect.Struct && !current.IsNil() {
+			kind = reflect.Struct
+		}
+		if (kind == reflect.Map || kind == reflect.Struct) && p.mergeable(current.Type()) {
+			merged := reflect.New(current.Type()).Elem()
+			merged.Set(current)
+			if err := p.patch(merged, m, path, sliceMode); err != nil {
+				return err
+			}
+			target.Set(merged)
+			return nil
+		}
+	}
+	merged := reflect.New(reflect.TypeFor[map[string]any]()).Elem()
+	if !merged.Type().AssignableTo(target.Type()) {
+		return p.replace(target, m, path)
+	}
+	if err := p.patchMap(merged, m, path); err != nil {
+		return err
+	}
+	target.Set(merged)
+	return nil
+}
+
+func (p *patcher) patchStruct(target reflect.Value, m map[string]any, path string) error {
+	used := make(map[string]bool, len(m))
+	var remain reflect.Value
+	errs := p.patchFields(target, m, path, used, &remain)
+	if remain.IsValid() && len(used) < len(m) {
+		unmatched := make(map[string]any, len(m)-len(used))
+		for key, item := range m {
+			if !used[key] {
+				unmatched[key] = item
+			}
+		}
+		errs = append(errs, p.patch(remain, unmatched, path, p.sliceMode))
+	}
+	return errors.Join(errs...)
+}
+
+// patchFields patches the fields of target, squashed ones included, marking the keys of m it used.
+// The first `,remain` field found is stored in remain.
+func (p *patcher) patchFields(
+	target reflect.Value,
+	m map[string]any,
+	path string,
+	used map[string]bool,
+	remain *reflect.Value,
+) []error {
+	t := target.Type()
+	errs := make([]error, 0)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, opts := p.d.fieldTag(field)
+		if name == "-" {
+			continue
+		}
+		if opts["remain"] {
+			if !remain.IsValid() && field.IsExported() && target.Field(i).Kind() == reflect.Map {
+				*remain = target.Field(i)
+			}
+			continue
+		}
+		squash := opts["squash"] || (p.d.squashTagOption != "" && opts[p.d.squashTagOption]) ||
+			(p.d.squash && field.Anonymous)
+		value := target.Field(i)
+		if squash {
+			if value.Kind() == reflect.Pointer {
+				if !value.CanSet() {
+					continue
+				}
+				patched := reflect.New(value.Type().Elem())
+				if !value.IsNil() {
+					patched.Elem().Set(value.Elem())
+				}
+				value.Set(patched)
+				value = patched.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				errs = append(errs, p.patchFields(value, m, path, used, remain)...)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		key, found, err := p.d.findKey(m, name, path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !found {
+			continue
+		}
+		used[key] = true
+		sliceMode := p.sliceMode
+		switch tag := field.Tag.Get("patch"); {
+		case p.mergePatch:
+		case tag == "append":
+			sliceMode = SliceAppend
+		case tag == "replace":
+			sliceMode = SliceReplace
+		default:
+		}
+		errs = append(errs, p.patch(value, m[key], joinKeyPath(path, key), sliceMode))
+	}
+	return errs
+}
+
+func (p *patcher) patchMap(target reflect.Value, m map[string]any, path string) error {
+	t := target.Type()
+	merged := reflect.MakeMapWithSize(t, target.Len()+len(m))
+	iter := target.MapRange()
+	for iter.Next() {
+		merged.SetMapIndex(iter.Key(), iter.Value())
+	}
+	errs := make([]error, 0)
+	for key, item := range m {
+		keyPath := joinKeyPath(path, key)
+		mapKey := reflect.New(t.Key()).Elem()
+		if err := p.replace(mapKey, key, keyPath); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if item == nil {
+			merged.SetMapIndex(mapKey, reflect.Value{})
+			continue
+		}
+		elem := reflect.New(t.Elem()).Elem()
+		if current := merged.MapIndex(mapKey); current.IsValid() {
+			elem.Set(current)
+		}
+		if err := p.patch(elem, item, keyPath, p.sliceMode); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		merged.SetMapIndex(mapKey, elem)
+	}
+	if len(errs) != 0 {
+		return errors.Join(errs...)
+	}
+	target.Set(merged)
+	return nil
+}
+
+func (p *patcher) appendSlice(target reflect.Value, src any, path string) error {
+	added := reflect.New(target.Type())
+	if err := p.d.decodeResolved(p.ctx, added.Interface(), src); err != nil {
+		return errors.Join(fmt.Errorf("failed to patch '%s'", pathOrRoot(path)), err)
+	}
+	// Cap the slice first so appending never writes into the backing array of the original.
+	target.Set(reflect.AppendSlice(target.Slice3(0, target.Len(), target.Len()), added.Elem()))
+	return nil
+}
+
+// replace decodes src into a fresh value of the target's type and stores it in target.
+func (p *patcher) replace(target reflect.Value, src any, path string) error {
+	result := reflect.New(target.Type())
+	if err := p.d.decodeResolved(p.ctx, result.Interface(), src); err != nil {
+		return errors.Join(fmt.Errorf("failed to patch '%s'", pathOrRoot(path)), err)
+	}
+	target.Set(result.Elem())
+	return nil
+}
+
+// mergeable reports whether values of t are merged key by key, rather than replaced by a self-decoding type.
+func (p *patcher) mergeable(t reflect.Type) bool {
+	base := t
+	if base.Kind() == reflect.Pointer {
+		base = base.Elem()
+	}
+	if factory, parse := p.d.registry.lookup(base); factory != nil || parse != nil {
+		return false
+	}
+	if _, ok := parseMethod(base); ok {
+		return false
+	}
+	ptr := reflect.PointerTo(base)
+	return !ptr.Implements(reflect.TypeFor[Decodable]()) && !ptr.Implements(jsonUnmarshalerType)
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
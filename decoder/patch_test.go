@@ -0,0 +1,147 @@
+package decoder_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/fmotalleb/go-tools/decoder"
+)
+
+type patchServer struct {
+	Host    string            `mapstructure:"host"`
+	Timeout time.Duration     `mapstructure:"timeout"`
+	Tags    []string          `mapstructure:"tags"`
+	Plugins []string          `mapstructure:"plugins" patch:"append"`
+	Labels  map[string]string `mapstructure:"labels"`
+	TLS     *struct {
+		Cert string `mapstructure:"cert"`
+		Key  string `mapstructure:"key"`
+	} `mapstructure:"tls"`
+}
+
+func newPatchServer(t *testing.T) patchServer {
+	t.Helper()
+	var out patchServer
+	assert.NoError(t, decoder.Decode(&out, map[string]any{
+		"host":    "localhost",
+		"timeout": "5s",
+		"tags":    "a,b",
+		"plugins": []any{"auth"},
+		"labels":  map[string]any{"env": "dev", "team": "core"},
+		"tls":     map[string]any{"cert": "c.pem", "key": "k.pem"},
+	}))
+	return out
+}
+
+func TestPatch(t *testing.T) {
+	out := newPatchServer(t)
+	err := decoder.Patch(&out, map[string]any{
+		"timeout": "1m",
+		"tags":    []any{"c"},
+		"plugins": "metrics",
+		"labels":  map[string]any{"env": "prod", "team": nil},
+		"tls":     map[string]any{"key": "new.pem"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", out.Host)
+	assert.Equal(t, time.Minute, out.Timeout)
+	assert.Equal(t, []string{"c"}, out.Tags)
+	assert.Equal(t, []string{"auth", "metrics"}, out.Plugins)
+	assert.Equal(t, map[string]string{"env": "prod"}, out.Labels)
+	assert.Equal(t, "c.pem", out.TLS.Cert)
+	assert.Equal(t, "new.pem", out.TLS.Key)
+}
+
+func TestPatch_NullClears(t *testing.T) {
+	out := newPatchServer(t)
+	err := decoder.Patch(&out, map[string]any{"host": nil, "tls": nil})
+	assert.NoError(t, err)
+	assert.Equal(t, "", out.Host)
+	assert.Zero(t, out.TLS)
+	assert.Equal(t, 5*time.Second, out.Timeout)
+}
+
+func TestPatch_SliceAppend(t *testing.T) {
+	out := newPatchServer(t)
+	err := decoder.Patch(&out, map[string]any{"tags": "c"}, decoder.WithSliceMode(decoder.SliceAppend))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, out.Tags)
+}
+
+func TestPatch_ErrorPath(t *testing.T) {
+	out := newPatchServer(t)
+	err := decoder.Patch(&out, map[string]any{"timeout": "soon"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "'timeout'")
+}
+
+func TestMergePatch(t *testing.T) {
+	out := newPatchServer(t)
+	err := decoder.MergePatch(&out, []byte(`{"host":"example.com","plugins":["x"],"labels":{"team":null},"tls":{"cert":null}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", out.Host)
+	assert.Equal(t, []string{"x"}, out.Plugins)
+	assert.Equal(t, map[string]string{"env": "dev"}, out.Labels)
+	assert.Equal(t, "", out.TLS.Cert)
+	assert.Equal(t, "k.pem", out.TLS.Key)
+
+	assert.Error(t, decoder.MergePatch(&out, []byte(`{`)))
+}
+
+func TestMergePatch_NestedMaps(t *testing.T) {
+	doc := []byte(`{"a":{"b":3,"c":null,"d":{"e":null,"f":1}},"x":null}`)
+	want := map[string]any{"a": map[string]any{"b": float64(3), "d": map[string]any{"f": float64(1)}}}
+
+	out := map[string]any{"a": map[string]any{"b": 1, "c": 2}, "x": true}
+	assert.NoError(t, decoder.MergePatch(&out, doc))
+	assert.Equal(t, want, out)
+
+	var anyOut any = map[string]any{"a": map[string]any{"b": 1, "c": 2}, "x": true}
+	assert.NoError(t, decoder.MergePatch(&anyOut, doc))
+	assert.Equal(t, any(want), anyOut)
+
+	var field struct {
+		Extra any `mapstructure:"extra"`
+	}
+	field.Extra = map[string]any{"keep": "yes", "drop": 1}
+	assert.NoError(t, decoder.MergePatch(&field, []byte(`{"extra":{"drop":null,"new":{"n":null}}}`)))
+	assert.Equal(t, any(map[string]any{"keep": "yes", "new": map[string]any{}}), field.Extra)
+
+	var scalar any = "text"
+	assert.NoError(t, decoder.MergePatch(&scalar, []byte(`{"a":{"b":null,"c":1}}`)))
+	assert.Equal(t, any(map[string]any{"a": map[string]any{"c": float64(1)}}), scalar)
+}
+
+func TestPatch_Remain(t *testing.T) {
+	var out struct {
+		Host  string         `mapstructure:"host"`
+		Extra map[string]any `mapstructure:",remain"`
+	}
+	assert.NoError(t, decoder.Decode(&out, map[string]any{"host": "a", "port": 80, "debug": true}))
+	assert.Equal(t, map[string]any{"port": 80, "debug": true}, out.Extra)
+
+	assert.NoError(t, decoder.MergePatch(&out, []byte(`{"host":"b","port":8080,"debug":null,"mode":"fast"}`)))
+	assert.Equal(t, "b", out.Host)
+	assert.Equal(t, map[string]any{"port": float64(8080), "mode": "fast"}, out.Extra)
+}
+
+func TestPatch_FailureLeavesTarget(t *testing.T) {
+	out := newPatchServer(t)
+	labels, tls := out.Labels, out.TLS
+	err := decoder.Patch(&out, map[string]any{
+		"host":    "remote",
+		"labels":  map[string]any{"env": "prod"},
+		"tls":     map[string]any{"key": "new.pem"},
+		"plugins": "metrics",
+		"timeout": "soon",
+	})
+	assert.Error(t, err)
+	assert.Equal(t, newPatchServer(t), out)
+	assert.Equal(t, map[string]string{"env": "dev", "team": "core"}, labels)
+	assert.Equal(t, "k.pem", tls.Key)
+
+	err = decoder.MergePatch(&out, []byte(`"not an object"`))
+	assert.Error(t, err)
+	assert.Equal(t, newPatchServer(t), out)
+}
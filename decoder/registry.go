@@ -0,0 +1,184 @@
+package decoder
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fmotalleb/go-tools/decoder/hooks"
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// DecodableFactory creates the [Decodable] used to decode into a registered type.
+type DecodableFactory = func() Decodable
+
+// ParseFunc converts a raw input into a registered type.
+type ParseFunc = func(val any) (any, error)
+
+// Registry holds the hooks, [Decodable] factories and parsers a [Decoder] uses on top of its defaults.
+// It is safe for concurrent use; changes are picked up by every [Decoder] sharing it.
+type Registry struct {
+	hooks *hooks.Registry
+
+	mu         sync.RWMutex
+	decodables map[reflect.Type]DecodableFactory
+	parsers    map[reflect.Type]ParseFunc
+
+	cacheMu sync.Mutex
+	cached  atomic.Pointer[composedHook]
+}
+
+// composedHook is the registered hook chain composed at a given hooks version.
+type composedHook struct {
+	hook    mapstructure.DecodeHookFunc
+	version uint64
+}
+
+var defaultRegistry = &Registry{
+	hooks:      hooks.Default(),
+	decodables: make(map[reflect.Type]DecodableFactory),
+	parsers:    make(map[reflect.Type]ParseFunc),
+}
+
+// DefaultRegistry returns the process-wide registry; its hook set is the one
+// [hooks.RegisterHook] writes into.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// NewRegistry creates a registry derived from a snapshot of [DefaultRegistry].
+// Later changes to either registry do not affect the other.
+func NewRegistry() *Registry {
+	return defaultRegistry.Clone()
+}
+
+// NewEmptyRegistry creates a registry without any hooks, decodables or parsers.
+func NewEmptyRegistry() *Registry {
+	return &Registry{
+		hooks:      hooks.NewRegistry(),
+		decodables: make(map[reflect.Type]DecodableFactory),
+		parsers:    make(map[reflect.Type]ParseFunc),
+	}
+}
+
+// Clone returns an independent copy of the registry.
+func (r *Registry) Clone() *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c := &Registry{
+		hooks:      r.hooks.Clone(),
+		decodables: make(map[reflect.Type]DecodableFactory, len(r.decodables)),
+		parsers:    make(map[reflect.Type]ParseFunc, len(r.parsers)),
+	}
+	for t, f := range r.decodables {
+		c.decodables[t] = f
+	}
+	for t, p := range r.parsers {
+		c.parsers[t] = p
+	}
+	return c
+}
+
+// RegisterHook appends hook to the registry; it runs after the default hook chain.
+func (r *Registry) RegisterHook(hook mapstructure.DecodeHookFunc) {
+	r.hooks.Register(hook)
+}
+
+// RegisterDecodable makes values decoded into t go through the [Decodable] built by factory.
+// t may be an interface type, e.g. io.Writer, as long as the decoded value implements it.
+func (r *Registry) RegisterDecodable(t reflect.Type, factory DecodableFactory) {
+	if t == nil || factory == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decodables[t] = factory
+}
+
+// RegisterParser makes values decoded into t go through parse.
+func (r *Registry) RegisterParser(t reflect.Type, parse ParseFunc) {
+	if t == nil || parse == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parsers[t] = parse
+}
+
+// RegisterParserIn registers a typed parser for T in r.
+func RegisterParserIn[T any](r *Registry, parse func(any) (T, error)) {
+	if parse == nil {
+		return
+	}
+	r.RegisterParser(reflect.TypeFor[T](), func(val any) (any, error) {
+		return parse(val)
+	})
+}
+
+// RegisterHook appends hook to [DefaultRegistry].
+func RegisterHook(hook mapstructure.DecodeHookFunc) {
+	defaultRegistry.RegisterHook(hook)
+}
+
+// RegisterDecodable registers factory for t in [DefaultRegistry].
+func RegisterDecodable(t reflect.Type, factory DecodableFactory) {
+	defaultRegistry.RegisterDecodable(t, factory)
+}
+
+// RegisterParser registers a typed parser for T in [DefaultRegistry].
+func RegisterParser[T any](parse func(any) (T, error)) {
+	RegisterParserIn(defaultRegistry, parse)
+}
+
+func (r *Registry) lookup(t reflect.Type) (DecodableFactory, ParseFunc) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.decodables[t], r.parsers[t]
+}
+
+// typeHook runs registered parsers and decodables for their target types.
+func (r *Registry) typeHook() mapstructure.DecodeHookFunc {
+	return func(from, to reflect.Value) (any, error) {
+		if !from.IsValid() {
+			return nil, nil
+		}
+		val := from.Interface()
+		if from.Type() == to.Type() {
+			return val, nil
+		}
+		factory, parse := r.lookup(to.Type())
+		switch {
+		case parse != nil:
+			return parse(val)
+		case factory != nil:
+			return factory().Decode(from.Type(), val)
+		default:
+			return val, nil
+		}
+	}
+}
+
+// extraHook runs the registered hooks, recomposing them only after the registry changes.
+func (r *Registry) extraHook() mapstructure.DecodeHookFunc {
+	return func(from, to reflect.Value) (any, error) {
+		return mapstructure.DecodeHookExec(r.composed(), from, to)
+	}
+}
+
+func (r *Registry) composed() mapstructure.DecodeHookFunc {
+	version := r.hooks.Version()
+	if c := r.cached.Load(); c != nil && c.version == version {
+		return c.hook
+	}
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+	if c := r.cached.Load(); c != nil && c.version == version {
+		return c.hook
+	}
+	c := &composedHook{
+		hook:    mapstructure.ComposeDecodeHookFunc(r.hooks.Hooks()...),
+		version: version,
+	}
+	r.cached.Store(c)
+	return c.hook
+}
@@ -0,0 +1,149 @@
+package decoder_test
+
+import (
+	"fmt"
+	"net/netip"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/fmotalleb/go-tools/decoder"
+	"github.com/fmotalleb/go-tools/decoder/variant"
+)
+
+type color struct {
+	name string
+}
+
+func TestRegistry_ScopedParsers(t *testing.T) {
+	upper := decoder.NewEmptyRegistry()
+	decoder.RegisterParserIn(upper, func(v any) (color, error) {
+		return color{name: strings.ToUpper(fmt.Sprint(v))}, nil
+	})
+	lower := decoder.NewEmptyRegistry()
+	decoder.RegisterParserIn(lower, func(v any) (color, error) {
+		return color{name: strings.ToLower(fmt.Sprint(v))}, nil
+	})
+
+	var a, b color
+	assert.NoError(t, decoder.New(decoder.WithRegistry(upper)).Decode(&a, "Red"))
+	assert.NoError(t, decoder.New(decoder.WithRegistry(lower)).Decode(&b, "Red"))
+	assert.Equal(t, "RED", a.name)
+	assert.Equal(t, "red", b.name)
+
+	var c color
+	assert.Error(t, decoder.New().Decode(&c, "Red"))
+}
+
+type greeter interface {
+	Greet() string
+}
+
+type english struct {
+	name string
+}
+
+func (e *english) Greet() string {
+	return "hello " + e.name
+}
+
+func (e *english) Decode(_ reflect.Type, val any) (any, error) {
+	e.name = fmt.Sprint(val)
+	return e, nil
+}
+
+func TestRegistry_DecodableForInterface(t *testing.T) {
+	r := decoder.NewRegistry()
+	r.RegisterDecodable(reflect.TypeFor[greeter](), func() decoder.Decodable {
+		return new(english)
+	})
+	var out struct {
+		Greeter greeter
+	}
+	assert.NoError(t, decoder.New(decoder.WithRegistry(r)).Decode(&out, map[string]any{"greeter": "bob"}))
+	assert.Equal(t, "hello bob", out.Greeter.Greet())
+}
+
+func TestRegistry_DerivedFromDefaultIsIndependent(t *testing.T) {
+	derived := decoder.NewRegistry()
+	calls := 0
+	derived.RegisterHook(func(_, _ reflect.Type, v any) (any, error) {
+		calls++
+		return v, nil
+	})
+	var out string
+	assert.NoError(t, decoder.Decode(&out, "value"))
+	assert.Equal(t, 0, calls)
+	assert.NoError(t, decoder.New(decoder.WithRegistry(derived)).Decode(&out, "value"))
+	assert.NotEqual(t, 0, calls)
+}
+
+func TestRegistry_ConcurrentRegisterAndDecode(t *testing.T) {
+	r := decoder.NewRegistry()
+	d := decoder.New(decoder.WithRegistry(r))
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Go(func() {
+			r.RegisterHook(func(_, _ reflect.Type, v any) (any, error) {
+				return v, nil
+			})
+		})
+		wg.Go(func() {
+			var out netip.AddrPort
+			assert.NoError(t, d.Decode(&out, "8080"))
+			assert.Equal(t, netip.MustParseAddrPort("127.0.0.1:8080"), out)
+		})
+	}
+	wg.Wait()
+}
+
+type notifier interface {
+	Target() string
+}
+
+type mailNotifier struct {
+	To      string   `mapstructure:"to"`
+	CC      []string `mapstructure:"cc"`
+	Subject string   `mapstructure:"subject"`
+}
+
+func (m mailNotifier) Target() string { return "mail:" + m.To }
+
+type hookNotifier struct {
+	URL string
+}
+
+func (h hookNotifier) Target() string { return "hook:" + h.URL }
+
+func TestRegisterVariant(t *testing.T) {
+	decoder.RegisterVariant("mail", func(opts mailNotifier) (notifier, error) {
+		return opts, nil
+	}, variant.WithArgs("to"))
+	decoder.RegisterVariant("webhook", func(url string) (notifier, error) {
+		return hookNotifier{URL: url}, nil
+	}, variant.WithAliases("hook"), variant.WithArgs("url"))
+
+	var out struct {
+		Primary  notifier
+		Fallback notifier
+		All      []notifier
+	}
+	err := decoder.Decode(&out, map[string]any{
+		"primary":  map[string]any{"type": "mail", "to": "ops@example.com", "cc": "a@example.com,b@example.com"},
+		"fallback": "hook:https://example.com/notify",
+		"all":      []any{"mail:dev@example.com", []any{"webhook", "https://example.com"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, notifier(mailNotifier{To: "ops@example.com", CC: []string{"a@example.com", "b@example.com"}}), out.Primary)
+	assert.Equal(t, notifier(hookNotifier{URL: "https://example.com/notify"}), out.Fallback)
+	assert.Equal(t, []notifier{
+		mailNotifier{To: "dev@example.com"},
+		hookNotifier{URL: "https://example.com"},
+	}, out.All)
+
+	err = decoder.Decode(&out, map[string]any{"primary": "sms:123"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "valid variants: mail, webhook")
+}
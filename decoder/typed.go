@@ -0,0 +1,34 @@
+package decoder
+
+// To decodes src into a new value of T using the [Default] decoder.
+//
+// Types decode themselves when they implement one of the interfaces below, at any depth (struct fields,
+// slice elements and map values). When a type implements several, the first in this order wins:
+//
+//  1. A parser or decodable registered in the [Registry] for the type.
+//  2. [Parsable] (`Parse(any) (T, error)` on *T), given the raw input.
+//  3. [Decodable], given the raw input.
+//  4. Built-in hooks for well-known types (durations, sizes, times, network types, ...).
+//  5. encoding.TextUnmarshaler, for string and []byte inputs.
+//  6. json.Unmarshaler, given the input marshaled to JSON.
+//
+// Inputs that already have the target type are never passed to these methods.
+func To[T any](src any) (T, error) {
+	return ToWith[T](Default(), src)
+}
+
+// ToWith decodes src into a new value of T using d, see [To].
+func ToWith[T any](d *Decoder, src any) (T, error) {
+	var result T
+	if err := d.Decode(&result, src); err != nil {
+		var zero T
+		return zero, err
+	}
+	return result, nil
+}
+
+// Into decodes src into dst using the [Default] decoder, it is the typed form of [Decode].
+// dst keeps the values of fields missing from src.
+func Into[T any](dst *T, src any) error {
+	return Default().Decode(dst, src)
+}
@@ -0,0 +1,129 @@
+package decoder_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/fmotalleb/go-tools/decoder"
+	"go.uber.org/zap/zapcore"
+)
+
+// priority implements Parsable.
+type priority int
+
+func (p *priority) Parse(val any) (priority, error) {
+	switch strings.ToLower(fmt.Sprint(val)) {
+	case "low":
+		return 1, nil
+	case "high":
+		return 10, nil
+	default:
+		return 0, fmt.Errorf("unknown priority %v", val)
+	}
+}
+
+// upper implements Decodable.
+type upper struct {
+	value string
+}
+
+func (u *upper) Decode(_ reflect.Type, val any) (any, error) {
+	u.value = strings.ToUpper(fmt.Sprint(val))
+	return u, nil
+}
+
+// point implements json.Unmarshaler.
+type point struct {
+	X, Y int
+}
+
+func (p *point) UnmarshalJSON(data []byte) error {
+	var pair []int
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return errors.New("expected [x, y]")
+	}
+	p.X, p.Y = pair[0], pair[1]
+	return nil
+}
+
+// everything implements Parsable, Decodable and encoding.TextUnmarshaler.
+type everything string
+
+func (e *everything) Parse(val any) (everything, error) {
+	return everything("parse:" + fmt.Sprint(val)), nil
+}
+
+func (e *everything) Decode(_ reflect.Type, val any) (any, error) {
+	return everything("decode:" + fmt.Sprint(val)), nil
+}
+
+func (e *everything) UnmarshalText(text []byte) error {
+	*e = everything("text:" + string(text))
+	return nil
+}
+
+type typedConfig struct {
+	Level      zapcore.Level
+	Priority   priority
+	Priorities map[string]priority
+	Name       upper
+	Names      []upper
+	Origin     point
+	Path       []point
+	Ptr        *priority
+	Winner     everything
+}
+
+func TestTo_DiscoversUnmarshalers(t *testing.T) {
+	cfg, err := decoder.To[typedConfig](map[string]any{
+		"level":      "debug",
+		"priority":   "high",
+		"priorities": map[string]any{"a": "low", "b": "HIGH"},
+		"name":       "api",
+		"names":      "a, b",
+		"origin":     []any{1, 2},
+		"path":       []any{[]any{0, 0}, []any{3, 4}},
+		"ptr":        "low",
+		"winner":     "x",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, zapcore.DebugLevel, cfg.Level)
+	assert.Equal(t, priority(10), cfg.Priority)
+	assert.Equal(t, map[string]priority{"a": 1, "b": 10}, cfg.Priorities)
+	assert.Equal(t, upper{value: "API"}, cfg.Name)
+	assert.Equal(t, []upper{{value: "A"}, {value: "B"}}, cfg.Names)
+	assert.Equal(t, point{X: 1, Y: 2}, cfg.Origin)
+	assert.Equal(t, []point{{}, {X: 3, Y: 4}}, cfg.Path)
+	assert.Equal(t, priority(1), *cfg.Ptr)
+	assert.Equal(t, everything("parse:x"), cfg.Winner)
+
+	_, err = decoder.To[typedConfig](map[string]any{"priority": "urgent"})
+	assert.Error(t, err)
+	_, err = decoder.To[typedConfig](map[string]any{"origin": []any{1}})
+	assert.Error(t, err)
+}
+
+func TestTo_Scalars(t *testing.T) {
+	p, err := decoder.To[priority]("low")
+	assert.NoError(t, err)
+	assert.Equal(t, priority(1), p)
+
+	ports, err := decoder.To[[]int]("80,443")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{80, 443}, ports)
+}
+
+func TestInto_KeepsExistingValues(t *testing.T) {
+	cfg := typedConfig{Priority: 1, Level: zapcore.WarnLevel}
+	assert.NoError(t, decoder.Into(&cfg, map[string]any{"level": "error"}))
+	assert.Equal(t, zapcore.ErrorLevel, cfg.Level)
+	assert.Equal(t, priority(1), cfg.Priority)
+}
@@ -0,0 +1,139 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ByteSize is a size in bytes that decodes from human-entered forms like `10MB`, `512KiB` or `1.5G`.
+//
+// Decimal units (KB, MB, ...) are powers of 1000 and binary units (KiB, MiB, ...) are powers of 1024.
+// The short forms (K, M, G, ...) and (Ki, Mi, Gi, ...) are aliases of their `B` suffixed counterparts.
+// Units are case-insensitive and a bare number is a byte count.
+type ByteSize int64
+
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+
+	KiB ByteSize = 1024 * Byte
+	MiB ByteSize = 1024 * KiB
+	GiB ByteSize = 1024 * MiB
+	TiB ByteSize = 1024 * GiB
+	PiB ByteSize = 1024 * TiB
+)
+
+type byteUnit struct {
+	name string
+	size ByteSize
+}
+
+// byteUnits is ordered from the largest unit down, binary before decimal on each level,
+// which is the order [ByteSize.String] picks a unit in.
+var byteUnits = []byteUnit{
+	{"PiB", PiB}, {"PB", PB},
+	{"TiB", TiB}, {"TB", TB},
+	{"GiB", GiB}, {"GB", GB},
+	{"MiB", MiB}, {"MB", MB},
+	{"KiB", KiB}, {"KB", KB},
+}
+
+var byteUnitAliases = map[string]ByteSize{
+	"": Byte, "b": Byte,
+	"k": KB, "kb": KB, "ki": KiB, "kib": KiB,
+	"m": MB, "mb": MB, "mi": MiB, "mib": MiB,
+	"g": GB, "gb": GB, "gi": GiB, "gib": GiB,
+	"t": TB, "tb": TB, "ti": TiB, "tib": TiB,
+	"p": PB, "pb": PB, "pi": PiB, "pib": PiB,
+}
+
+// maxFractionDigits bounds the fraction [ByteSize.String] is willing to print before
+// falling back to a smaller unit.
+const maxFractionDigits = 3
+
+// ParseByteSize parses a human-entered size such as `10MB`, `512KiB` or `1.5G`.
+func ParseByteSize(s string) (ByteSize, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return 0, errors.New("empty byte size")
+	}
+	split := strings.IndexFunc(str, func(r rune) bool {
+		return unicode.IsLetter(r)
+	})
+	if split == -1 {
+		split = len(str)
+	}
+	number := strings.TrimSpace(str[:split])
+	unit, ok := byteUnitAliases[strings.ToLower(strings.TrimSpace(str[split:]))]
+	if !ok {
+		return 0, fmt.Errorf("unknown byte size unit in '%s'", s)
+	}
+	if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+		if n != 0 && (n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit)) {
+			return 0, fmt.Errorf("byte size '%s' overflows int64", s)
+		}
+		return ByteSize(n) * unit, nil
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse input '%s' into byte size: %w", s, err)
+	}
+	value := f * float64(unit)
+	if value >= math.MaxInt64 || value < math.MinInt64 {
+		return 0, fmt.Errorf("byte size '%s' overflows int64", s)
+	}
+	return ByteSize(math.Round(value)), nil
+}
+
+// String formats the size using the largest unit that represents it with at most
+// three fraction digits, e.g. `10MB`, `512KiB` or `1.5GB`.
+func (b ByteSize) String() string {
+	abs := b
+	if abs < 0 {
+		abs = -abs
+	}
+	for _, u := range byteUnits {
+		if abs < u.size {
+			continue
+		}
+		value := float64(b) / float64(u.size)
+		str := strconv.FormatFloat(value, 'f', -1, 64)
+		if dot := strings.IndexByte(str, '.'); dot != -1 && len(str)-dot-1 > maxFractionDigits {
+			continue
+		}
+		if parsed, err := ParseByteSize(str + u.name); err != nil || parsed != b {
+			continue
+		}
+		return str + u.name
+	}
+	return strconv.FormatInt(int64(b), 10) + "B"
+}
+
+// Bytes returns the size as a plain byte count.
+func (b ByteSize) Bytes() int64 {
+	return int64(b)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	parsed, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = parsed
+	return nil
+}
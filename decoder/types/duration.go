@@ -0,0 +1,148 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fmotalleb/go-tools/constants"
+)
+
+// Week is seven days, as accepted by [ParseDuration].
+const Week = 7 * constants.Day
+
+// Duration is a time.Duration that also accepts days (`d`), weeks (`w`) and
+// years (`y`), e.g. `7d`, `2w`, `1y` or `1d12h`.
+// A day is [constants.Day] and a year is [constants.Year], calendar effects are ignored.
+type Duration time.Duration
+
+var extendedDurationUnits = []struct {
+	name string
+	size time.Duration
+}{
+	{"y", constants.Year},
+	{"w", Week},
+	{"d", constants.Day},
+}
+
+// ParseDuration parses a duration string, accepting every time.ParseDuration form plus `d`, `w` and `y` units.
+func ParseDuration(s string) (time.Duration, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return 0, errors.New("empty duration")
+	}
+	if d, err := time.ParseDuration(str); err == nil {
+		return d, nil
+	}
+	neg := false
+	switch str[0] {
+	case '-':
+		neg = true
+		str = str[1:]
+	case '+':
+		str = str[1:]
+	}
+	var total time.Duration
+	for str != "" {
+		numEnd := strings.IndexFunc(str, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.'
+		})
+		if numEnd <= 0 {
+			return 0, fmt.Errorf("invalid duration '%s'", s)
+		}
+		unitEnd := numEnd + strings.IndexFunc(str[numEnd:], func(r rune) bool {
+			return (r >= '0' && r <= '9') || r == '.'
+		})
+		if unitEnd < numEnd {
+			unitEnd = len(str)
+		}
+		part, err := parseDurationPart(str[:numEnd], str[numEnd:unitEnd])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%s': %w", s, err)
+		}
+		if part > math.MaxInt64-total {
+			return 0, fmt.Errorf("invalid duration '%s': overflows time.Duration", s)
+		}
+		total += part
+		str = str[unitEnd:]
+	}
+	if neg {
+		total = -total
+	}
+	return total, nil
+}
+
+func parseDurationPart(number, unit string) (time.Duration, error) {
+	for _, u := range extendedDurationUnits {
+		if unit != u.name {
+			continue
+		}
+		f, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return 0, err
+		}
+		value := f * float64(u.size)
+		if value >= math.MaxInt64 {
+			return 0, errors.New("overflows time.Duration")
+		}
+		return time.Duration(value), nil
+	}
+	return time.ParseDuration(number + unit)
+}
+
+// String formats the duration using the extended units where they apply, e.g. `1w2d3h30m`.
+func (d Duration) String() string {
+	value := time.Duration(d)
+	if value == 0 {
+		return "0s"
+	}
+	b := new(strings.Builder)
+	if value < 0 {
+		b.WriteByte('-')
+		value = -value
+	}
+	for _, u := range extendedDurationUnits {
+		if n := value / u.size; n > 0 {
+			b.WriteString(strconv.FormatInt(int64(n), 10))
+			b.WriteString(u.name)
+			value %= u.size
+		}
+	}
+	if h := value / time.Hour; h > 0 {
+		b.WriteString(strconv.FormatInt(int64(h), 10))
+		b.WriteByte('h')
+		value %= time.Hour
+	}
+	if m := value / time.Minute; m > 0 {
+		b.WriteString(strconv.FormatInt(int64(m), 10))
+		b.WriteByte('m')
+		value %= time.Minute
+	}
+	if value > 0 {
+		b.WriteString(value.String())
+	}
+	return b.String()
+}
+
+// Duration returns the value as a time.Duration.
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,80 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// FileMode is an fs.FileMode that decodes from octal strings like `0644`, `644` or `0o644`.
+type FileMode fs.FileMode
+
+// ParseFileMode parses an octal permission string; a leading `0` or `0o` is optional.
+func ParseFileMode(s string) (fs.FileMode, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return 0, errors.New("empty file mode")
+	}
+	str = strings.TrimPrefix(strings.TrimPrefix(str, "0o"), "0O")
+	mode, err := strconv.ParseUint(str, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse input '%s' into file mode: %w", s, err)
+	}
+	if mode > 0o7777 {
+		return 0, fmt.Errorf("file mode '%s' exceeds permission bits", s)
+	}
+	return permBits(uint32(mode)), nil
+}
+
+// permBits maps unix permission bits (including setuid, setgid and sticky) onto fs.FileMode.
+func permBits(mode uint32) fs.FileMode {
+	result := fs.FileMode(mode) & fs.ModePerm
+	if mode&0o4000 != 0 {
+		result |= fs.ModeSetuid
+	}
+	if mode&0o2000 != 0 {
+		result |= fs.ModeSetgid
+	}
+	if mode&0o1000 != 0 {
+		result |= fs.ModeSticky
+	}
+	return result
+}
+
+// String formats the mode as a four digit octal string, e.g. `0644`.
+func (m FileMode) String() string {
+	mode := fs.FileMode(m)
+	bits := uint32(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		bits |= 0o4000
+	}
+	if mode&fs.ModeSetgid != 0 {
+		bits |= 0o2000
+	}
+	if mode&fs.ModeSticky != 0 {
+		bits |= 0o1000
+	}
+	return fmt.Sprintf("%04o", bits)
+}
+
+// FileMode returns the value as an fs.FileMode.
+func (m FileMode) FileMode() fs.FileMode {
+	return fs.FileMode(m)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m FileMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *FileMode) UnmarshalText(text []byte) error {
+	parsed, err := ParseFileMode(string(text))
+	if err != nil {
+		return err
+	}
+	*m = FileMode(parsed)
+	return nil
+}
@@ -0,0 +1,380 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// DefaultHost is the address used when only a port is given, e.g. `8080`.
+var DefaultHost = netip.AddrFrom4([4]byte{127, 0, 0, 1})
+
+// ParseAddrPort parses an IPv4 or IPv6 address with a port.
+//
+// It accepts `1.2.3.4:80`, `[::1]:80`, a bare port `80` (bound to [DefaultHost]),
+// and `:80` (bound to the unspecified IPv4 address, i.e. any-address).
+func ParseAddrPort(s string) (netip.AddrPort, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return netip.AddrPort{}, errors.New("empty address")
+	}
+	if isDigits(str) {
+		port, err := ParsePort(str)
+		if err != nil {
+			return netip.AddrPort{}, err
+		}
+		return netip.AddrPortFrom(DefaultHost, port), nil
+	}
+	if rest, ok := strings.CutPrefix(str, ":"); ok {
+		port, err := ParsePort(rest)
+		if err != nil {
+			return netip.AddrPort{}, err
+		}
+		return netip.AddrPortFrom(netip.IPv4Unspecified(), port), nil
+	}
+	addrPort, err := netip.ParseAddrPort(str)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("failed to parse input '%s' into address:port: %w", s, err)
+	}
+	return addrPort, nil
+}
+
+// ParseAddr parses an IPv4 or IPv6 address, IPv6 may be wrapped in brackets (`[::1]`).
+func ParseAddr(s string) (netip.Addr, error) {
+	str := strings.TrimSpace(s)
+	if strings.HasPrefix(str, "[") && strings.HasSuffix(str, "]") {
+		str = str[1 : len(str)-1]
+	}
+	addr, err := netip.ParseAddr(str)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to parse input '%s' into address: %w", s, err)
+	}
+	return addr, nil
+}
+
+// ParsePrefix parses a CIDR prefix such as `10.0.0.0/8` or `fd00::/8`.
+// A bare address is taken as a single host prefix (/32 or /128).
+func ParsePrefix(s string) (netip.Prefix, error) {
+	str := strings.TrimSpace(s)
+	if !strings.Contains(str, "/") {
+		addr, err := ParseAddr(str)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(str)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("failed to parse input '%s' into prefix: %w", s, err)
+	}
+	return prefix, nil
+}
+
+// ParsePort parses a port number in the range 0-65535.
+func ParsePort(s string) (uint16, error) {
+	port, err := strconv.ParseUint(strings.TrimSpace(s), 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port '%s': %w", s, err)
+	}
+	return uint16(port), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// HostPort is a host and port pair where the host may be a hostname, an IPv4 or an IPv6 address.
+// An empty host means any-address.
+type HostPort struct {
+	Host string
+	Port uint16
+}
+
+// ParseHostPort parses `example.com:80`, `10.0.0.1:80`, `[::1]:80`, `:80` or a bare port `80`.
+// A bare port leaves the host empty, same as `:80`.
+func ParseHostPort(s string) (HostPort, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return HostPort{}, errors.New("empty host:port")
+	}
+	if isDigits(str) {
+		port, err := ParsePort(str)
+		return HostPort{Port: port}, err
+	}
+	host, portStr, err := net.SplitHostPort(str)
+	if err != nil {
+		return HostPort{}, fmt.Errorf("failed to parse input '%s' into host:port: %w", s, err)
+	}
+	port, err := ParsePort(portStr)
+	if err != nil {
+		return HostPort{}, err
+	}
+	return HostPort{Host: host, Port: port}, nil
+}
+
+// String formats the pair as `host:port`, wrapping IPv6 hosts in brackets.
+func (h HostPort) String() string {
+	return net.JoinHostPort(h.Host, strconv.FormatUint(uint64(h.Port), 10))
+}
+
+// IsAny reports whether the host is empty or an unspecified address.
+func (h HostPort) IsAny() bool {
+	if h.Host == "" {
+		return true
+	}
+	addr, err := netip.ParseAddr(h.Host)
+	return err == nil && addr.IsUnspecified()
+}
+
+// AddrPort converts the pair into netip.AddrPort; it fails if the host is a hostname.
+// An empty host maps to the unspecified IPv4 address.
+func (h HostPort) AddrPort() (netip.AddrPort, error) {
+	if h.Host == "" {
+		return netip.AddrPortFrom(netip.IPv4Unspecified(), h.Port), nil
+	}
+	addr, err := netip.ParseAddr(h.Host)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("host '%s' is not an ip address: %w", h.Host, err)
+	}
+	return netip.AddrPortFrom(addr, h.Port), nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (h HostPort) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (h *HostPort) UnmarshalText(text []byte) error {
+	parsed, err := ParseHostPort(string(text))
+	if err != nil {
+		return err
+	}
+	*h = parsed
+	return nil
+}
+
+// PortRange is an inclusive range of ports such as `8000-8100`; a single port has Start == End.
+type PortRange struct {
+	Start uint16
+	End   uint16
+}
+
+// ParsePortRange parses `8000-8100` or a single port `80`.
+func ParsePortRange(s string) (PortRange, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return PortRange{}, errors.New("empty port range")
+	}
+	startStr, endStr, isRange := strings.Cut(str, "-")
+	start, err := ParsePort(startStr)
+	if err != nil {
+		return PortRange{}, err
+	}
+	if !isRange {
+		return PortRange{Start: start, End: start}, nil
+	}
+	end, err := ParsePort(endStr)
+	if err != nil {
+		return PortRange{}, err
+	}
+	if end < start {
+		return PortRange{}, fmt.Errorf("invalid port range '%s': end is before start", s)
+	}
+	return PortRange{Start: start, End: end}, nil
+}
+
+// Contains reports whether port is within the range.
+func (r PortRange) Contains(port uint16) bool {
+	return port >= r.Start && port <= r.End
+}
+
+// Len returns the number of ports in the range.
+func (r PortRange) Len() int {
+	return int(r.End) - int(r.Start) + 1
+}
+
+// String formats the range as `start-end`, or `port` for a single port.
+func (r PortRange) String() string {
+	if r.Start == r.End {
+		return strconv.FormatUint(uint64(r.Start), 10)
+	}
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (r PortRange) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (r *PortRange) UnmarshalText(text []byte) error {
+	parsed, err := ParsePortRange(string(text))
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// PrefixList is a list of prefixes, decoded from a list or a comma-separated string.
+type PrefixList []netip.Prefix
+
+// ParsePrefixList parses a comma-separated list of prefixes, see [ParsePrefix].
+func ParsePrefixList(s string) (PrefixList, error) {
+	result := make(PrefixList, 0)
+	for item := range strings.SplitSeq(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		prefix, err := ParsePrefix(item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, prefix)
+	}
+	return result, nil
+}
+
+// Contains reports whether any prefix in the list contains addr.
+func (l PrefixList) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range l {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the list as comma-separated prefixes.
+func (l PrefixList) String() string {
+	items := make([]string, len(l))
+	for i, prefix := range l {
+		items[i] = prefix.String()
+	}
+	return strings.Join(items, ",")
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (l PrefixList) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (l *PrefixList) UnmarshalText(text []byte) error {
+	parsed, err := ParsePrefixList(string(text))
+	if err != nil {
+		return err
+	}
+	*l = parsed
+	return nil
+}
+
+// NetAddr is a network address that implements net.Addr.
+//
+// It decodes from `unix:/run/app.sock` (or `unix:///run/app.sock`), `udp:host:port`,
+// `tcp://host:port` and a plain `host:port`, which defaults to tcp.
+type NetAddr struct {
+	Net     string
+	Address string
+}
+
+var _ net.Addr = NetAddr{}
+
+// ParseNetAddr parses a network address, see [NetAddr].
+// Address parts of tcp and udp networks are validated with [ParseHostPort].
+func ParseNetAddr(s string) (NetAddr, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return NetAddr{}, errors.New("empty network address")
+	}
+	network := "tcp"
+	address := str
+	if scheme, rest, ok := strings.Cut(str, ":"); ok && isNetwork(scheme) {
+		network = scheme
+		address = rest
+		if trimmed, ok := strings.CutPrefix(rest, "//"); ok {
+			address = trimmed
+		}
+	}
+	if address == "" {
+		return NetAddr{}, fmt.Errorf("missing address in '%s'", s)
+	}
+	if !isUnixNetwork(network) {
+		hostPort, err := ParseHostPort(address)
+		if err != nil {
+			return NetAddr{}, err
+		}
+		address = hostPort.String()
+	}
+	return NetAddr{Net: network, Address: address}, nil
+}
+
+func isNetwork(name string) bool {
+	switch name {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+		return true
+	default:
+		return isUnixNetwork(name)
+	}
+}
+
+func isUnixNetwork(name string) bool {
+	switch name {
+	case "unix", "unixgram", "unixpacket":
+		return true
+	default:
+		return false
+	}
+}
+
+// Network implements net.Addr.
+func (a NetAddr) Network() string {
+	return a.Net
+}
+
+// String implements net.Addr, it returns the address alone as accepted by net.Dial and net.Listen.
+func (a NetAddr) String() string {
+	return a.Address
+}
+
+// Qualified formats the address as `network:address`, omitting the network for tcp,
+// the form accepted by [ParseNetAddr].
+func (a NetAddr) Qualified() string {
+	if a.Net == "" || a.Net == "tcp" {
+		return a.Address
+	}
+	return a.Net + ":" + a.Address
+}
+
+// IsUnix reports whether the address is a unix socket.
+func (a NetAddr) IsUnix() bool {
+	return isUnixNetwork(a.Net)
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the [NetAddr.Qualified] form.
+func (a NetAddr) MarshalText() ([]byte, error) {
+	return []byte(a.Qualified()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *NetAddr) UnmarshalText(text []byte) error {
+	parsed, err := ParseNetAddr(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
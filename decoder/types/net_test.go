@@ -0,0 +1,219 @@
+package types_test
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/fmotalleb/go-tools/decoder"
+	"github.com/fmotalleb/go-tools/decoder/types"
+)
+
+func TestParseAddrPort(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "8080", want: "127.0.0.1:8080"},
+		{input: ":8080", want: "0.0.0.0:8080"},
+		{input: "10.0.0.1:80", want: "10.0.0.1:80"},
+		{input: "[::1]:8080", want: "[::1]:8080"},
+		{input: "[fe80::1%eth0]:443", want: "[fe80::1%eth0]:443"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := types.ParseAddrPort(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, netip.MustParseAddrPort(tt.want), got)
+		})
+	}
+	for _, bad := range []string{"", "::1:8080", "localhost:80", "70000", "10.0.0.1"} {
+		_, err := types.ParseAddrPort(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestParseHostPort(t *testing.T) {
+	tests := []struct {
+		input string
+		want  types.HostPort
+		str   string
+		any   bool
+	}{
+		{input: "example.com:443", want: types.HostPort{Host: "example.com", Port: 443}, str: "example.com:443"},
+		{input: "10.0.0.1:80", want: types.HostPort{Host: "10.0.0.1", Port: 80}, str: "10.0.0.1:80"},
+		{input: "[::1]:8080", want: types.HostPort{Host: "::1", Port: 8080}, str: "[::1]:8080"},
+		{input: ":8080", want: types.HostPort{Port: 8080}, str: ":8080", any: true},
+		{input: "8080", want: types.HostPort{Port: 8080}, str: ":8080", any: true},
+		{input: "0.0.0.0:53", want: types.HostPort{Host: "0.0.0.0", Port: 53}, str: "0.0.0.0:53", any: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := types.ParseHostPort(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.str, got.String())
+			assert.Equal(t, tt.any, got.IsAny())
+		})
+	}
+	for _, bad := range []string{"", "example.com", "::1:80", "host:http", "host:70000"} {
+		_, err := types.ParseHostPort(bad)
+		assert.Error(t, err, bad)
+	}
+
+	_, err := types.HostPort{Host: "example.com", Port: 1}.AddrPort()
+	assert.Error(t, err)
+}
+
+func TestParsePortRange(t *testing.T) {
+	tests := []struct {
+		input string
+		want  types.PortRange
+		str   string
+		len   int
+	}{
+		{input: "8000-8100", want: types.PortRange{Start: 8000, End: 8100}, str: "8000-8100", len: 101},
+		{input: "80", want: types.PortRange{Start: 80, End: 80}, str: "80", len: 1},
+		{input: " 1 - 1024 ", want: types.PortRange{Start: 1, End: 1024}, str: "1-1024", len: 1024},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := types.ParsePortRange(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.str, got.String())
+			assert.Equal(t, tt.len, got.Len())
+			assert.True(t, got.Contains(tt.want.End))
+		})
+	}
+	for _, bad := range []string{"", "-", "8100-8000", "80-", "1-70000"} {
+		_, err := types.ParsePortRange(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestParsePrefix(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "10.0.0.0/8", want: "10.0.0.0/8"},
+		{input: "fd00::/8", want: "fd00::/8"},
+		{input: "10.1.2.3", want: "10.1.2.3/32"},
+		{input: "::1", want: "::1/128"},
+		{input: "[::1]", want: "::1/128"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := types.ParsePrefix(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, netip.MustParsePrefix(tt.want), got)
+		})
+	}
+	for _, bad := range []string{"", "10.0.0.0/33", "example.com"} {
+		_, err := types.ParsePrefix(bad)
+		assert.Error(t, err, bad)
+	}
+
+	list, err := types.ParsePrefixList("10.0.0.0/8, 192.168.0.0/16,,fd00::/8")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.0/8,192.168.0.0/16,fd00::/8", list.String())
+	assert.True(t, list.Contains(netip.MustParseAddr("192.168.1.1")))
+	assert.True(t, list.Contains(netip.MustParseAddr("::ffff:10.1.1.1")))
+	assert.False(t, list.Contains(netip.MustParseAddr("172.16.0.1")))
+}
+
+func TestParseNetAddr(t *testing.T) {
+	tests := []struct {
+		input   string
+		network string
+		address string
+		str     string
+	}{
+		{input: "unix:/run/app.sock", network: "unix", address: "/run/app.sock", str: "unix:/run/app.sock"},
+		{input: "unix:///run/app.sock", network: "unix", address: "/run/app.sock", str: "unix:/run/app.sock"},
+		{input: "unix:@abstract", network: "unix", address: "@abstract", str: "unix:@abstract"},
+		{input: "tcp://localhost:80", network: "tcp", address: "localhost:80", str: "localhost:80"},
+		{input: "udp:[::1]:53", network: "udp", address: "[::1]:53", str: "udp:[::1]:53"},
+		{input: "example.com:443", network: "tcp", address: "example.com:443", str: "example.com:443"},
+		{input: ":8080", network: "tcp", address: ":8080", str: ":8080"},
+		{input: "[::1]:8080", network: "tcp", address: "[::1]:8080", str: "[::1]:8080"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := types.ParseNetAddr(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.network, got.Network())
+			assert.Equal(t, tt.address, got.Address)
+			assert.Equal(t, tt.address, got.String())
+			assert.Equal(t, tt.str, got.Qualified())
+
+			text, err := got.MarshalText()
+			assert.NoError(t, err)
+			back, err := types.ParseNetAddr(string(text))
+			assert.NoError(t, err)
+			assert.Equal(t, got, back)
+		})
+	}
+	for _, bad := range []string{"", "unix:", "tcp://", "tcp:host"} {
+		_, err := types.ParseNetAddr(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestDecodeNet(t *testing.T) {
+	var out struct {
+		Listen   netip.AddrPort
+		Local    netip.AddrPort
+		Any      netip.AddrPort
+		Port     netip.AddrPort
+		Addr     netip.Addr
+		Subnet   netip.Prefix
+		Trusted  types.PrefixList
+		Allowed  []netip.Prefix
+		Upstream types.HostPort
+		Metrics  types.HostPort
+		Ports    types.PortRange
+		Socket   types.NetAddr
+		Network  net.IPNet
+	}
+	err := decoder.Decode(&out, map[string]any{
+		"listen":   "[::1]:8080",
+		"local":    "8080",
+		"any":      ":9090",
+		"port":     9091,
+		"addr":     "[fd00::1]",
+		"subnet":   "10.0.0.0/8",
+		"trusted":  "10.0.0.0/8,192.168.0.0/16",
+		"allowed":  []any{"172.16.0.0/12", "fd00::/8"},
+		"upstream": "api.internal:443",
+		"metrics":  9100,
+		"ports":    "8000-8100",
+		"socket":   "unix:/run/app.sock",
+		"network":  "192.168.1.10/24",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddrPort("[::1]:8080"), out.Listen)
+	assert.Equal(t, netip.MustParseAddrPort("127.0.0.1:8080"), out.Local)
+	assert.Equal(t, netip.MustParseAddrPort("0.0.0.0:9090"), out.Any)
+	assert.Equal(t, netip.MustParseAddrPort("127.0.0.1:9091"), out.Port)
+	assert.Equal(t, netip.MustParseAddr("fd00::1"), out.Addr)
+	assert.Equal(t, netip.MustParsePrefix("10.0.0.0/8"), out.Subnet)
+	assert.Equal(t, types.PrefixList{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.0.0/16"),
+	}, out.Trusted)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("172.16.0.0/12"),
+		netip.MustParsePrefix("fd00::/8"),
+	}, out.Allowed)
+	assert.Equal(t, types.HostPort{Host: "api.internal", Port: 443}, out.Upstream)
+	assert.Equal(t, types.HostPort{Port: 9100}, out.Metrics)
+	assert.Equal(t, types.PortRange{Start: 8000, End: 8100}, out.Ports)
+	assert.Equal(t, types.NetAddr{Net: "unix", Address: "/run/app.sock"}, out.Socket)
+	assert.Equal(t, "192.168.1.0/24", out.Network.String())
+
+	var bad struct{ Ports types.PortRange }
+	assert.Error(t, decoder.Decode(&bad, map[string]any{"ports": 70000}))
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Percent is a ratio that decodes from `75%` (0.75) or from a plain ratio like `0.75`.
+type Percent float64
+
+// percentPrecision rounds away float noise (e.g. 0.07*100) when formatting.
+const percentPrecision = 1e9
+
+// ParsePercent parses `75%` into 0.75; input without a `%` suffix is taken as the ratio itself.
+func ParsePercent(s string) (float64, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return 0, errors.New("empty percentage")
+	}
+	number, isPercent := strings.CutSuffix(str, "%")
+	f, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse input '%s' into percentage: %w", s, err)
+	}
+	if isPercent {
+		f /= 100
+	}
+	return f, nil
+}
+
+// String formats the ratio as a percentage, e.g. `75%`.
+func (p Percent) String() string {
+	value := math.Round(float64(p)*100*percentPrecision) / percentPrecision
+	return strconv.FormatFloat(value, 'f', -1, 64) + "%"
+}
+
+// Ratio returns the value as a plain ratio.
+func (p Percent) Ratio() float64 {
+	return float64(p)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p Percent) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *Percent) UnmarshalText(text []byte) error {
+	parsed, err := ParsePercent(string(text))
+	if err != nil {
+		return err
+	}
+	*p = Percent(parsed)
+	return nil
+}
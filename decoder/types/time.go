@@ -0,0 +1,170 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeLayouts are the layouts tried by [ParseTime] when none are given.
+var DefaultTimeLayouts = []string{
+	time.RFC3339,
+	time.DateTime,
+	time.DateOnly,
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// epochMillisThreshold separates epoch seconds from epoch milliseconds; seconds reach it in year 33658.
+const epochMillisThreshold = 1e12
+
+// ParseTime parses s as a relative expression (see [ParseRelativeTime]), with the first matching layout
+// (Default [DefaultTimeLayouts]) or, failing those, as a unix epoch in seconds or milliseconds
+// (see [EpochToTime]), so all-digit layouts such as `20060102` take precedence over the epoch.
+func ParseTime(s string, layouts ...string) (time.Time, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return time.Time{}, errors.New("empty time")
+	}
+	if t, ok, err := ParseRelativeTime(str, time.Now()); ok {
+		return t, err
+	}
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts
+	}
+	errs := make([]error, 0, len(layouts))
+	for _, layout := range layouts {
+		parsed, err := time.Parse(layout, str)
+		if err == nil {
+			return parsed, nil
+		}
+		errs = append(errs, err)
+	}
+	if epoch, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return EpochToTime(epoch), nil
+	}
+	return time.Time{}, errors.Join(
+		fmt.Errorf("failed to parse input '%s' into time.Time", s),
+		errors.Join(errs...),
+	)
+}
+
+// ParseRelativeTime parses `now`, `now-1h` or `now+2d` relative to now, offsets accept [ParseDuration] units.
+// ok reports whether s is a relative expression at all.
+func ParseRelativeTime(s string, now time.Time) (t time.Time, ok bool, err error) {
+	str := strings.ToLower(strings.TrimSpace(s))
+	rest, ok := strings.CutPrefix(str, "now")
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return now, true, nil
+	}
+	sign := rest[0]
+	if sign != '+' && sign != '-' {
+		return time.Time{}, true, fmt.Errorf("invalid relative time '%s': expected `now+<duration>` or `now-<duration>`", s)
+	}
+	offset, err := ParseDuration(strings.TrimSpace(rest[1:]))
+	if err != nil {
+		return time.Time{}, true, fmt.Errorf("invalid relative time '%s': %w", s, err)
+	}
+	if sign == '-' {
+		offset = -offset
+	}
+	return now.Add(offset), true, nil
+}
+
+// EpochToTime converts a unix epoch into a time, values of 1e12 and above are taken as milliseconds.
+func EpochToTime(epoch int64) time.Time {
+	if epoch >= epochMillisThreshold || epoch <= -epochMillisThreshold {
+		return time.UnixMilli(epoch)
+	}
+	return time.Unix(epoch, 0)
+}
+
+// ParseWeekday parses weekday names (`monday`), their three letter abbreviations (`Mon`) or numbers
+// where 0 is Sunday, case-insensitively.
+func ParseWeekday(s string) (time.Weekday, error) {
+	str := strings.ToLower(strings.TrimSpace(s))
+	if n, err := strconv.Atoi(str); err == nil {
+		if n < int(time.Sunday) || n > int(time.Saturday) {
+			return 0, fmt.Errorf("weekday %d out of range 0-6", n)
+		}
+		return time.Weekday(n), nil
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if str == name || (len(str) == 3 && strings.HasPrefix(name, str)) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("failed to parse input '%s' into weekday", s)
+}
+
+// TimeOfDay is a wall clock time without a date, decoded from `HH:MM` or `HH:MM:SS`.
+type TimeOfDay struct {
+	Hour   int
+	Minute int
+	Second int
+}
+
+// ParseTimeOfDay parses a 24-hour clock time such as `09:30`, `9:30` or `23:59:59`.
+func ParseTimeOfDay(s string) (TimeOfDay, error) {
+	str := strings.TrimSpace(s)
+	parts := strings.Split(str, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return TimeOfDay{}, fmt.Errorf("failed to parse input '%s' into time of day: expected HH:MM or HH:MM:SS", s)
+	}
+	limits := []int{23, 59, 59}
+	values := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || n > limits[i] || (i > 0 && len(part) != 2) {
+			return TimeOfDay{}, fmt.Errorf("failed to parse input '%s' into time of day", s)
+		}
+		values[i] = n
+	}
+	return TimeOfDay{Hour: values[0], Minute: values[1], Second: values[2]}, nil
+}
+
+// Duration returns the time elapsed since midnight.
+func (t TimeOfDay) Duration() time.Duration {
+	return time.Duration(t.Hour)*time.Hour + time.Duration(t.Minute)*time.Minute + time.Duration(t.Second)*time.Second
+}
+
+// On returns the time of day on the date of day, in its location.
+func (t TimeOfDay) On(day time.Time) time.Time {
+	year, month, date := day.Date()
+	return time.Date(year, month, date, t.Hour, t.Minute, t.Second, 0, day.Location())
+}
+
+// Before reports whether t is earlier in the day than other.
+func (t TimeOfDay) Before(other TimeOfDay) bool {
+	return t.Duration() < other.Duration()
+}
+
+// String formats the time as `HH:MM`, or `HH:MM:SS` when seconds are set.
+func (t TimeOfDay) String() string {
+	if t.Second != 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", t.Hour, t.Minute, t.Second)
+	}
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t TimeOfDay) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *TimeOfDay) UnmarshalText(text []byte) error {
+	parsed, err := ParseTimeOfDay(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
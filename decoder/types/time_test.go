@@ -0,0 +1,168 @@
+package types_test
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/fmotalleb/go-tools/constants"
+	"github.com/fmotalleb/go-tools/decoder"
+	"github.com/fmotalleb/go-tools/decoder/types"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{input: "2024-03-01T10:20:30Z", want: time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC)},
+		{input: "2024-03-01 10:20:30", want: time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC)},
+		{input: "2024-03-01", want: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{input: "Fri, 01 Mar 2024 10:20:30 +0000", want: time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC)},
+		{input: "Fri, 01 Mar 2024 10:20:30 UTC", want: time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC)},
+		{input: "1709288430", want: time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC)},
+		{input: "1709288430500", want: time.Date(2024, 3, 1, 10, 20, 30, 5e8, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := types.ParseTime(tt.input)
+			assert.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), got.String())
+		})
+	}
+	for _, bad := range []string{"", "yesterday", "2024-13-01", "now*2"} {
+		_, err := types.ParseTime(bad)
+		assert.Error(t, err, bad)
+	}
+
+	got, err := types.ParseTime("01/03/2024", "02/01/2006")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), got)
+
+	got, err = types.ParseTime("20240301", "20060102")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), got)
+
+	got, err = types.ParseTime("102030", "150405")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(0, 1, 1, 10, 20, 30, 0, time.UTC), got)
+
+	got, err = types.ParseTime("1709288430", "20060102")
+	assert.NoError(t, err)
+	assert.True(t, time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC).Equal(got), got.String())
+}
+
+func TestParseRelativeTime(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{input: "now", want: now},
+		{input: "now-1h", want: now.Add(-time.Hour)},
+		{input: "NOW + 2d", want: now.Add(2 * constants.Day)},
+		{input: "now-1w2d", want: now.Add(-9 * constants.Day)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok, err := types.ParseRelativeTime(tt.input, now)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	_, ok, _ := types.ParseRelativeTime("2024-03-01", now)
+	assert.False(t, ok)
+	_, ok, err := types.ParseRelativeTime("now-soon", now)
+	assert.True(t, ok)
+	assert.Error(t, err)
+}
+
+func TestParseWeekday(t *testing.T) {
+	tests := map[string]time.Weekday{
+		"monday": time.Monday,
+		"Sat":    time.Saturday,
+		"SUNDAY": time.Sunday,
+		"3":      time.Wednesday,
+		" thu ":  time.Thursday,
+	}
+	for input, want := range tests {
+		got, err := types.ParseWeekday(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+	for _, bad := range []string{"", "mo", "7", "funday"} {
+		_, err := types.ParseWeekday(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestParseTimeOfDay(t *testing.T) {
+	tests := []struct {
+		input string
+		want  types.TimeOfDay
+		str   string
+	}{
+		{input: "09:30", want: types.TimeOfDay{Hour: 9, Minute: 30}, str: "09:30"},
+		{input: "9:05", want: types.TimeOfDay{Hour: 9, Minute: 5}, str: "09:05"},
+		{input: "23:59:59", want: types.TimeOfDay{Hour: 23, Minute: 59, Second: 59}, str: "23:59:59"},
+		{input: "00:00", want: types.TimeOfDay{}, str: "00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := types.ParseTimeOfDay(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.str, got.String())
+		})
+	}
+	for _, bad := range []string{"", "9", "24:00", "12:60", "12:5", "1:2:3:4"} {
+		_, err := types.ParseTimeOfDay(bad)
+		assert.Error(t, err, bad)
+	}
+
+	start := types.TimeOfDay{Hour: 22}
+	day := time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC), start.On(day))
+	assert.True(t, types.TimeOfDay{Hour: 6}.Before(start))
+}
+
+func TestDecodeTime(t *testing.T) {
+	var out struct {
+		Start  time.Time
+		Epoch  time.Time
+		Millis time.Time
+		Since  time.Time
+		Zone   *time.Location
+		Day    time.Weekday
+		Days   []time.Weekday
+		Window types.TimeOfDay
+		NumDay time.Weekday
+	}
+	before := time.Now()
+	err := decoder.Decode(&out, map[string]any{
+		"start":  "2024-03-01",
+		"epoch":  1709288430,
+		"millis": "1709288430500",
+		"since":  "now-1h",
+		"zone":   "Europe/Berlin",
+		"day":    "sat",
+		"days":   "mon,tue, fri",
+		"window": "02:30",
+		"numday": 5,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), out.Start)
+	assert.Equal(t, int64(1709288430), out.Epoch.Unix())
+	assert.Equal(t, int64(1709288430500), out.Millis.UnixMilli())
+	assert.True(t, !out.Since.Before(before.Add(-time.Hour)) && out.Since.Before(before))
+	assert.Equal(t, "Europe/Berlin", out.Zone.String())
+	assert.Equal(t, time.Saturday, out.Day)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Tuesday, time.Friday}, out.Days)
+	assert.Equal(t, types.TimeOfDay{Hour: 2, Minute: 30}, out.Window)
+	assert.Equal(t, time.Friday, out.NumDay)
+
+	var bad struct{ Zone *time.Location }
+	assert.Error(t, decoder.Decode(&bad, map[string]any{"zone": "Mars/Olympus"}))
+}
@@ -0,0 +1,155 @@
+package types_test
+
+import (
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/fmotalleb/go-tools/constants"
+	"github.com/fmotalleb/go-tools/decoder"
+	"github.com/fmotalleb/go-tools/decoder/types"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  types.ByteSize
+		str   string
+	}{
+		{input: "10MB", want: 10 * types.MB, str: "10MB"},
+		{input: "512KiB", want: 512 * types.KiB, str: "512KiB"},
+		{input: "1.5G", want: 1500 * types.MB, str: "1.5GB"},
+		{input: "1.5GiB", want: 1536 * types.MiB, str: "1.5GiB"},
+		{input: "2 mb", want: 2 * types.MB, str: "2MB"},
+		{input: "100", want: 100, str: "100B"},
+		{input: "1536b", want: 1536, str: "1.5KiB"},
+		{input: "0", want: 0, str: "0B"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := types.ParseByteSize(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.str, got.String())
+
+			var back types.ByteSize
+			assert.NoError(t, back.UnmarshalText([]byte(got.String())))
+			assert.Equal(t, got, back)
+		})
+	}
+
+	for _, bad := range []string{"", "MB", "10XB", "1.2.3KB", "99999999999PB", "8.0EiB", "-9.0EiB"} {
+		_, err := types.ParseByteSize(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+		str   string
+	}{
+		{input: "7d", want: 7 * constants.Day, str: "1w"},
+		{input: "2w", want: 2 * types.Week, str: "2w"},
+		{input: "1y", want: constants.Year, str: "1y"},
+		{input: "1d12h", want: constants.Day + 12*time.Hour, str: "1d12h"},
+		{input: "1.5d", want: 36 * time.Hour, str: "1d12h"},
+		{input: "90m", want: 90 * time.Minute, str: "1h30m"},
+		{input: "1h0m1.5s", want: time.Hour + 1500*time.Millisecond, str: "1h1.5s"},
+		{input: "-3d", want: -3 * constants.Day, str: "-3d"},
+		{input: "250ms", want: 250 * time.Millisecond, str: "250ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := types.ParseDuration(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.str, types.Duration(got).String())
+
+			back, err := types.ParseDuration(types.Duration(got).String())
+			assert.NoError(t, err)
+			assert.Equal(t, got, back)
+		})
+	}
+
+	for _, bad := range []string{"", "d", "3x", "1d-2h", "300y", "-300y", "200y100y", "2562047h1d"} {
+		_, err := types.ParseDuration(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestParsePercent(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+		str   string
+	}{
+		{input: "75%", want: 0.75, str: "75%"},
+		{input: "7%", want: 0.07, str: "7%"},
+		{input: "12.5 %", want: 0.125, str: "12.5%"},
+		{input: "0.3", want: 0.3, str: "30%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := types.ParsePercent(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.str, types.Percent(got).String())
+		})
+	}
+	_, err := types.ParsePercent("half")
+	assert.Error(t, err)
+}
+
+func TestParseFileMode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  fs.FileMode
+		str   string
+	}{
+		{input: "0644", want: 0o644, str: "0644"},
+		{input: "755", want: 0o755, str: "0755"},
+		{input: "0o600", want: 0o600, str: "0600"},
+		{input: "1777", want: fs.ModeSticky | 0o777, str: "1777"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := types.ParseFileMode(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.str, types.FileMode(got).String())
+		})
+	}
+	for _, bad := range []string{"", "0999", "17777"} {
+		_, err := types.ParseFileMode(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestDecodeUnits(t *testing.T) {
+	var out struct {
+		MaxSize   types.ByteSize
+		Retention time.Duration
+		TTL       types.Duration
+		Threshold types.Percent
+		Mode      fs.FileMode
+		DirMode   types.FileMode
+	}
+	err := decoder.Decode(&out, map[string]any{
+		"maxsize":   "10MB",
+		"retention": "7d",
+		"ttl":       "2w",
+		"threshold": "75%",
+		"mode":      "0644",
+		"dirmode":   0o755,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 10*types.MB, out.MaxSize)
+	assert.Equal(t, 7*constants.Day, out.Retention)
+	assert.Equal(t, types.Duration(2*types.Week), out.TTL)
+	assert.Equal(t, types.Percent(0.75), out.Threshold)
+	assert.Equal(t, fs.FileMode(0o644), out.Mode)
+	assert.Equal(t, types.FileMode(0o755), out.DirMode)
+}
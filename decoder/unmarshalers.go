@@ -0,0 +1,120 @@
+package decoder
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	anyType             = reflect.TypeFor[any]()
+	errorType           = reflect.TypeFor[error]()
+)
+
+// selfDecodingHook runs [Parsable] and [Decodable] implementations of the target type.
+func selfDecodingHook() mapstructure.DecodeHookFunc {
+	return func(from, to reflect.Value) (any, error) {
+		if !from.IsValid() {
+			return nil, nil
+		}
+		val := from.Interface()
+		target := to.Type()
+		if skipSelfDecoding(from.Type(), target) {
+			return val, nil
+		}
+		if parse, ok := parseMethod(target); ok {
+			out := parse.Call([]reflect.Value{reflect.ValueOf(&val).Elem()})
+			if err, _ := out[1].Interface().(error); err != nil {
+				return nil, err
+			}
+			return out[0].Interface(), nil
+		}
+		if opt, ok := reflect.New(target).Interface().(Decodable); ok {
+			result, err := opt.Decode(from.Type(), val)
+			if err != nil {
+				return nil, err
+			}
+			return derefTo(result, target), nil
+		}
+		return val, nil
+	}
+}
+
+// unmarshalerHook runs encoding.TextUnmarshaler and json.Unmarshaler implementations of the target type.
+func unmarshalerHook() mapstructure.DecodeHookFunc {
+	return func(from, to reflect.Value) (any, error) {
+		if !from.IsValid() {
+			return nil, nil
+		}
+		val := from.Interface()
+		target := to.Type()
+		if skipSelfDecoding(from.Type(), target) {
+			return val, nil
+		}
+		ptr := reflect.PointerTo(target)
+		isText := from.Kind() == reflect.String ||
+			(from.Kind() == reflect.Slice && from.Type().Elem().Kind() == reflect.Uint8)
+		switch {
+		case isText && ptr.Implements(textUnmarshalerType):
+			result := reflect.New(target)
+			text := []byte(from.String())
+			if from.Kind() == reflect.Slice {
+				text = from.Bytes()
+			}
+			if err := result.Interface().(encoding.TextUnmarshaler).UnmarshalText(text); err != nil {
+				return nil, err
+			}
+			return result.Elem().Interface(), nil
+		case ptr.Implements(jsonUnmarshalerType):
+			data, err := json.Marshal(val)
+			if err != nil {
+				return nil, errors.Join(fmt.Errorf("failed to marshal input for %s", target), err)
+			}
+			result := reflect.New(target)
+			if err := result.Interface().(json.Unmarshaler).UnmarshalJSON(data); err != nil {
+				return nil, err
+			}
+			return result.Elem().Interface(), nil
+		default:
+			return val, nil
+		}
+	}
+}
+
+// skipSelfDecoding reports whether the input needs no conversion, or the target cannot implement methods.
+func skipSelfDecoding(from, to reflect.Type) bool {
+	switch to.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return true
+	default:
+		return from == to || (from.Kind() == reflect.Pointer && from.Elem() == to)
+	}
+}
+
+// parseMethod returns the bound Parse method of a new *t when it implements [Parsable] of t.
+func parseMethod(t reflect.Type) (reflect.Value, bool) {
+	method, ok := reflect.PointerTo(t).MethodByName("Parse")
+	if !ok {
+		return reflect.Value{}, false
+	}
+	mt := method.Type
+	if mt.NumIn() != 2 || mt.In(1) != anyType || mt.NumOut() != 2 || mt.Out(0) != t || mt.Out(1) != errorType {
+		return reflect.Value{}, false
+	}
+	return reflect.New(t).MethodByName("Parse"), true
+}
+
+// derefTo returns *result when result points to a value of t, as Decodable implementations often return themselves.
+func derefTo(result any, t reflect.Type) any {
+	v := reflect.ValueOf(result)
+	if v.Kind() == reflect.Pointer && !v.IsNil() && v.Type().Elem() == t {
+		return v.Elem().Interface()
+	}
+	return result
+}
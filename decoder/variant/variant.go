@@ -0,0 +1,373 @@
+// Package variant decodes interface values from a type discriminator.
+//
+// A [Registry] holds the named implementations (variants) of an interface I, each built by a factory
+// from its own options type. A value selects its variant by one of:
+//
+//	{type: rotate, path: /var/log/app.log, max_size: 10}   // map with a discriminator key
+//	rotate:/var/log/app.log                                // `name:arg` string
+//	[rotate, /var/log/app.log]                             // `[name, args...]` slice
+//
+// The remaining map keys, or the positional args mapped onto [WithArgs] keys, are decoded into the
+// variant's options type.
+//
+// This package is a leaf so packages below decoder (e.g. matcher) can use it; the decoder package
+// wires the global registries into its hook chain.
+package variant
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// DecodeFunc decodes src into the pointer dst.
+type DecodeFunc = func(dst any, src any) error
+
+// Decoder is the type-erased view of a [Registry].
+type Decoder interface {
+	// DecodeAny builds the variant selected by src, decoding its options with decode.
+	DecodeAny(src any, decode DecodeFunc) (any, error)
+	// Names returns the sorted canonical variant names.
+	Names() []string
+}
+
+// Option configures a registered variant.
+type Option = func(*entry)
+
+// RegistryOption configures a [Registry].
+type RegistryOption = func(*config)
+
+type config struct {
+	key  string
+	def  string
+	seps []string
+}
+
+type entry struct {
+	name    string
+	aliases []string
+	args    []string
+	build   func(params map[string]any, hasParams bool, decode DecodeFunc) (any, error)
+}
+
+// WithAliases adds alternative names for a variant.
+func WithAliases(aliases ...string) Option {
+	return func(e *entry) {
+		e.aliases = append(e.aliases, aliases...)
+	}
+}
+
+// WithArgs names the options keys that positional args of `name:arg` strings and `[name, args...]`
+// slices are stored under (Default `arg`).
+func WithArgs(keys ...string) Option {
+	return func(e *entry) {
+		e.args = keys
+	}
+}
+
+// WithKey sets the discriminator key of map inputs (Default `type`).
+func WithKey(key string) RegistryOption {
+	return func(c *config) {
+		c.key = key
+	}
+}
+
+// WithDefault sets the variant used when the discriminator is missing, or when a string has no
+// separator at all, in which case the whole string is its arg.
+func WithDefault(name string) RegistryOption {
+	return func(c *config) {
+		c.def = name
+	}
+}
+
+// WithSeparators sets the separators between name and arg in string inputs (Default `:`).
+// The first separator that yields a registered name wins.
+func WithSeparators(seps ...string) RegistryOption {
+	return func(c *config) {
+		c.seps = seps
+	}
+}
+
+// Registry holds the variants of interface I. It is safe for concurrent use.
+type Registry[I any] struct {
+	mu       sync.RWMutex
+	cfg      config
+	variants map[string]*entry
+}
+
+// NewRegistry creates an empty [Registry] for I.
+func NewRegistry[I any](opts ...RegistryOption) *Registry[I] {
+	r := &Registry[I]{
+		cfg: config{
+			key:  "type",
+			seps: []string{":"},
+		},
+		variants: make(map[string]*entry),
+	}
+	for _, opt := range opts {
+		opt(&r.cfg)
+	}
+	return r
+}
+
+var registries sync.Map // reflect.Type -> Decoder
+
+// For returns the global [Registry] of I, creating it on first use. Use [Registry.Configure] to alter it.
+func For[I any]() *Registry[I] {
+	t := reflect.TypeFor[I]()
+	if r, ok := registries.Load(t); ok {
+		return r.(*Registry[I])
+	}
+	r, _ := registries.LoadOrStore(t, NewRegistry[I]())
+	return r.(*Registry[I])
+}
+
+// Lookup returns the global registry of the interface type t, if any variant was registered for it.
+func Lookup(t reflect.Type) (Decoder, bool) {
+	r, ok := registries.Load(t)
+	if !ok {
+		return nil, false
+	}
+	return r.(Decoder), true
+}
+
+// Register adds the variant name to r. factory receives the variant's options decoded into O.
+// A later registration with the same name replaces the former one.
+func Register[I any, O any](r *Registry[I], name string, factory func(O) (I, error), opts ...Option) {
+	e := &entry{
+		name: name,
+		args: []string{"arg"},
+		build: func(params map[string]any, hasParams bool, decode DecodeFunc) (any, error) {
+			var options O
+			if err := decodeOptions(&options, params, hasParams, decode); err != nil {
+				return nil, errors.Join(fmt.Errorf("failed to decode options of variant '%s'", name), err)
+			}
+			return factory(options)
+		},
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.variants[name] = e
+	for _, alias := range e.aliases {
+		r.variants[alias] = e
+	}
+}
+
+// Configure applies opts to r.
+func (r *Registry[I]) Configure(opts ...RegistryOption) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, opt := range opts {
+		opt(&r.cfg)
+	}
+}
+
+// Names returns the sorted canonical variant names, aliases excluded.
+func (r *Registry[I]) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.namesLocked()
+}
+
+// Decode builds the variant selected by src. Options are decoded with decode, or with a plain
+// weakly typed mapstructure decoder when decode is nil.
+func (r *Registry[I]) Decode(src any, decode DecodeFunc) (I, error) {
+	var zero I
+	result, err := r.DecodeAny(src, decode)
+	if err != nil {
+		return zero, err
+	}
+	value, ok := result.(I)
+	if !ok {
+		return zero, fmt.Errorf("variant built %T, which is not %s", result, reflect.TypeFor[I]())
+	}
+	return value, nil
+}
+
+// DecodeAny implements [Decoder].
+func (r *Registry[I]) DecodeAny(src any, decode DecodeFunc) (any, error) {
+	if decode == nil {
+		decode = plainDecode
+	}
+	e, in, err := r.resolve(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(in.args) > len(e.args) {
+		return nil, fmt.Errorf("variant '%s' accepts at most %d args, got %d", in.name, len(e.args), len(in.args))
+	}
+	hasParams := in.params != nil || in.args != nil
+	if hasParams && in.params == nil {
+		in.params = make(map[string]any, len(in.args))
+	}
+	for i, arg := range in.args {
+		in.params[e.args[i]] = arg
+	}
+	return e.build(in.params, hasParams, decode)
+}
+
+// resolve finds the variant selected by src. The lock is released before the variant is built,
+// so options may hold nested variants of the same interface.
+func (r *Registry[I]) resolve(src any) (*entry, input, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	in, err := r.split(src)
+	if err != nil {
+		return nil, in, err
+	}
+	e, ok := r.variants[in.name]
+	if !ok {
+		return nil, in, r.unknown(in.name)
+	}
+	return e, in, nil
+}
+
+// input is a src split into the variant name, its named params and its positional args.
+type input struct {
+	name   string
+	params map[string]any
+	args   []any
+}
+
+// split extracts the variant name and its params from src; r.mu must be held.
+func (r *Registry[I]) split(src any) (input, error) {
+	value := reflect.ValueOf(src)
+	switch {
+	case !value.IsValid():
+		return input{name: r.cfg.def}, r.requireDefault("empty value")
+	case value.Kind() == reflect.String:
+		return r.splitString(value.String())
+	case value.Kind() == reflect.Map:
+		params := make(map[string]any, value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			key := reflect.ValueOf(iter.Key().Interface())
+			if key.Kind() != reflect.String {
+				return input{}, errors.New("map key must be a string")
+			}
+			params[key.String()] = iter.Value().Interface()
+		}
+		raw, ok := params[r.cfg.key]
+		delete(params, r.cfg.key)
+		if !ok || raw == nil {
+			return input{name: r.cfg.def, params: params}, r.requireDefault(fmt.Sprintf("missing `%s` key", r.cfg.key))
+		}
+		name, ok := raw.(string)
+		if !ok {
+			return input{}, fmt.Errorf("`%s` key must be a string, got %T", r.cfg.key, raw)
+		}
+		return input{name: name, params: params}, nil
+	case value.Kind() == reflect.Slice || value.Kind() == reflect.Array:
+		if value.Len() == 0 {
+			return input{name: r.cfg.def}, r.requireDefault("empty list")
+		}
+		name, ok := value.Index(0).Interface().(string)
+		if !ok {
+			return input{}, fmt.Errorf("first element must be the variant name, got %T", value.Index(0).Interface())
+		}
+		args := make([]any, 0, value.Len()-1)
+		for i := 1; i < value.Len(); i++ {
+			args = append(args, value.Index(i).Interface())
+		}
+		return input{name: name, args: args}, nil
+	default:
+		return input{}, fmt.Errorf("unsupported %T input, expected a map, a string or a list", src)
+	}
+}
+
+// splitString handles `name`, `name:arg` and, with a default variant, a bare arg; r.mu must be held.
+// A prefix that is not a registered name is an error even with a default variant.
+func (r *Registry[I]) splitString(str string) (input, error) {
+	if _, ok := r.variants[str]; ok {
+		return input{name: str}, nil
+	}
+	for _, sep := range r.cfg.seps {
+		if name, arg, ok := strings.Cut(str, sep); ok {
+			if _, known := r.variants[name]; known {
+				return input{name: name, args: []any{arg}}, nil
+			}
+		}
+	}
+	name := str
+	for _, sep := range r.cfg.seps {
+		name, _, _ = strings.Cut(name, sep)
+	}
+	if name == str && r.cfg.def != "" {
+		return input{name: r.cfg.def, args: []any{str}}, nil
+	}
+	return input{}, r.unknown(name)
+}
+
+func (r *Registry[I]) requireDefault(reason string) error {
+	if r.cfg.def != "" {
+		return nil
+	}
+	return fmt.Errorf("%s: cannot select %s variant, valid variants: %s",
+		reason, reflect.TypeFor[I](), strings.Join(r.namesLocked(), ", "))
+}
+
+func (r *Registry[I]) unknown(name string) error {
+	return fmt.Errorf("unknown %s variant '%s', valid variants: %s",
+		reflect.TypeFor[I](), name, strings.Join(r.namesLocked(), ", "))
+}
+
+func (r *Registry[I]) namesLocked() []string {
+	names := make([]string, 0, len(r.variants))
+	for key, e := range r.variants {
+		if key == e.name {
+			names = append(names, key)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// decodeOptions decodes params into dst. Options types that are not structs or maps receive the single
+// param value (e.g. the `arg` of `glob:*.go`) instead of the whole map.
+func decodeOptions(dst any, params map[string]any, hasParams bool, decode DecodeFunc) error {
+	if !hasParams {
+		return nil
+	}
+	target := reflect.TypeOf(dst).Elem()
+	for target.Kind() == reflect.Pointer {
+		target = target.Elem()
+	}
+	switch target.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Interface:
+		return decode(dst, params)
+	default:
+		switch len(params) {
+		case 0:
+			return nil
+		case 1:
+			for _, value := range params {
+				return decode(dst, value)
+			}
+		}
+		return fmt.Errorf("expected a single value for %s, got %d", target, len(params))
+	}
+}
+
+func plainDecode(dst any, src any) error {
+	d, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           dst,
+		WeaklyTypedInput: true,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+			mapstructure.TextUnmarshallerHookFunc(),
+		),
+	})
+	if err != nil {
+		return err
+	}
+	return d.Decode(src)
+}
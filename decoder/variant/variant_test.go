@@ -0,0 +1,102 @@
+package variant_test
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/fmotalleb/go-tools/decoder/variant"
+)
+
+type shape interface {
+	Area() float64
+}
+
+type square struct {
+	Side float64 `mapstructure:"side"`
+}
+
+func (s square) Area() float64 { return s.Side * s.Side }
+
+type rect struct {
+	Width  float64 `mapstructure:"width"`
+	Height float64 `mapstructure:"height"`
+}
+
+func (r rect) Area() float64 { return r.Width * r.Height }
+
+func newShapes(opts ...variant.RegistryOption) *variant.Registry[shape] {
+	r := variant.NewRegistry[shape](opts...)
+	variant.Register(r, "square", func(side float64) (shape, error) {
+		return square{Side: side}, nil
+	}, variant.WithAliases("sq"), variant.WithArgs("side"))
+	variant.Register(r, "rect", func(opts rect) (shape, error) {
+		return opts, nil
+	}, variant.WithArgs("width", "height"))
+	return r
+}
+
+func TestRegistry_Decode(t *testing.T) {
+	tests := []struct {
+		name  string
+		input any
+		want  shape
+	}{
+		{name: "map", input: map[string]any{"type": "rect", "width": 2, "height": "3"}, want: rect{Width: 2, Height: 3}},
+		{name: "yaml map", input: map[any]any{"type": "square", "side": 4}, want: square{Side: 4}},
+		{name: "string", input: "square:5", want: square{Side: 5}},
+		{name: "alias", input: "sq:6", want: square{Side: 6}},
+		{name: "slice", input: []any{"rect", 2, 5}, want: rect{Width: 2, Height: 5}},
+		{name: "partial slice", input: []any{"rect", 2}, want: rect{Width: 2}},
+		{name: "name only", input: "rect", want: rect{}},
+	}
+	r := newShapes()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Decode(tt.input, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRegistry_Errors(t *testing.T) {
+	r := newShapes()
+	tests := []struct {
+		name  string
+		input any
+		msg   string
+	}{
+		{name: "unknown string", input: "circle:1", msg: "unknown variant_test.shape variant 'circle', valid variants: rect, square"},
+		{name: "unknown map", input: map[string]any{"type": "circle"}, msg: "valid variants: rect, square"},
+		{name: "missing key", input: map[string]any{"side": 1}, msg: "missing `type` key"},
+		{name: "too many args", input: []any{"square", 1, 2}, msg: "accepts at most 1 args"},
+		{name: "bad options", input: "square:wide", msg: "failed to decode options of variant 'square'"},
+		{name: "unsupported", input: 42, msg: "unsupported int input"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := r.Decode(tt.input, nil)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.msg)
+		})
+	}
+}
+
+func TestRegistry_Default(t *testing.T) {
+	r := newShapes(variant.WithDefault("square"), variant.WithKey("kind"))
+
+	got, err := r.Decode("7", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, shape(square{Side: 7}), got)
+
+	got, err = r.Decode(map[string]any{"side": 2}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, shape(square{Side: 2}), got)
+
+	got, err = r.Decode(map[string]any{"kind": "rect", "width": 1, "height": 1}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, shape(rect{Width: 1, Height: 1}), got)
+
+	_, err = r.Decode("circle:1", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,40 @@
+package decoder
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/fmotalleb/go-tools/decoder/variant"
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// RegisterVariant registers name as an implementation of the interface I.
+//
+// Fields of type I then decode from `{type: name, ...}` maps, `name:arg` strings or `[name, args...]`
+// slices; the remaining keys or args are decoded into O, which is passed to factory.
+// See the variant package for the accepted inputs and options.
+func RegisterVariant[I any, O any](name string, factory func(O) (I, error), opts ...variant.Option) {
+	variant.Register(variant.For[I](), name, factory, opts...)
+}
+
+// variantHook builds values of interfaces with registered variants, decoding their options with d
+// and reporting their `alias` and `deprecated` keys through the logger of ctx.
+func (d *Decoder) variantHook(ctx context.Context) mapstructure.DecodeHookFunc {
+	decode := func(dst any, src any) error {
+		return d.decode(ctx, dst, src)
+	}
+	return func(from, to reflect.Value) (any, error) {
+		if !from.IsValid() {
+			return nil, nil
+		}
+		val := from.Interface()
+		if to.Kind() != reflect.Interface || from.Type().Implements(to.Type()) {
+			return val, nil
+		}
+		variants, ok := variant.Lookup(to.Type())
+		if !ok {
+			return val, nil
+		}
+		return variants.DecodeAny(val, decode)
+	}
+}
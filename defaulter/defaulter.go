@@ -1,22 +1,30 @@
 package defaulter
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
 	"reflect"
+	"strings"
 
 	"github.com/fmotalleb/go-tools/decoder"
-	"github.com/fmotalleb/go-tools/env"
 	"github.com/fmotalleb/go-tools/template"
 )
 
+// Option alters a single [ApplyDefaults] call.
+type Option = func(*walker)
+
 // ApplyDefaults walks v - which should be a pointer, typically to a struct -
 // and fills every zero-valued field tagged with `default:"..."`. If the
 // field also carries an `env:"..."` tag, the environment variable it names
-// takes precedence over the static default (but a value already set by the
-// caller, e.g. from a decoded config file, is never overwritten - only zero
-// fields are touched). Default values may be Go templates (e.g.
-// `default:"{{.Some.Field}}"`), evaluated against data.
+// (prefixed as described in [WithEnvPrefix]) takes precedence over the
+// static default (but a value already set by the caller, e.g. from a
+// decoded config file, is never overwritten - only zero fields are touched). Default values may be Go templates (e.g.
+// `default:"{{.Some.Field}}"`), evaluated against data, or against a [Scope]
+// when they reference sibling or parent fields (`{{ .Self.Timeout }}`).
+// Structs implementing [Defaulter] or [ContextDefaulter] are called once
+// the tag defaults of their own fields and nested values are in place.
 //
 // It recurses into nested structs, slices/arrays, maps, and pointers.
 // Nil struct pointers are allocated so that defaults can still be applied
@@ -26,14 +34,50 @@ import (
 // Any errors encountered while decoding a default value into its target
 // field are collected and returned via errors.Join rather than discarded;
 // a nil return means every tagged field that needed a default got one.
-func ApplyDefaults(v any, data any) error {
-	visited := make(map[uintptr]bool)
-	var errs []error
-	applyRecursive(data, reflect.ValueOf(v), visited, &errs)
-	return errors.Join(errs...)
+func ApplyDefaults(v any, data any, opts ...Option) error {
+	w := &walker{
+		ctx:     context.Background(),
+		data:    data,
+		visited: make(map[uintptr]bool),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	w.apply(reflect.ValueOf(v), "", w.envPrefix)
+	return errors.Join(w.errs...)
+}
+
+// walker holds the state of a single [ApplyDefaults] call.
+type walker struct {
+	ctx       context.Context
+	data      any
+	visited   map[uintptr]bool
+	errs      []error
+	report    *Report
+	envPrefix string
+	// scopes holds the structs being filled, outermost first.
+	scopes []reflect.Value
+}
+
+// WithEnvPrefix prepends prefix to every `env` tag, ahead of the `envPrefix` tags of struct fields.
+// A field tagged `envPrefix:"PRIMARY_"` prepends it to the `env` tags of the value it holds, so a
+// shared type gets distinct variables per use (`PRIMARY_HOST`, `REPLICA_HOST`). Elements of slices
+// and arrays beneath a prefix add their index, e.g. `BACKEND_0_HOST`.
+func WithEnvPrefix(prefix string) Option {
+	return func(w *walker) {
+		w.envPrefix = prefix
+	}
 }
 
-func applyRecursive(data any, val reflect.Value, visited map[uintptr]bool, errs *[]error) {
+// WithContext sets the context passed to [ContextDefaulter] implementations (Default context.Background).
+func WithContext(ctx context.Context) Option {
+	return func(w *walker) {
+		w.ctx = ctx
+	}
+}
+
+// apply fills val, prefix is prepended to the `env` tags beneath it.
+func (w *walker) apply(val reflect.Value, path string, prefix string) {
 	if !val.IsValid() {
 		return
 	}
@@ -63,56 +107,40 @@ func applyRecursive(data any, val reflect.Value, visited map[uintptr]bool, errs
 			// or further pointers). For basic types like *string or
 			// *int there is nothing to recurse into, and keeping them
 			// nil lets callers distinguish "unset" from "set to zero".
-		switch val.Type().Elem().Kind() {
-		case reflect.Struct:
-			if !hasTaggedFields(val.Type().Elem()) {
+			switch val.Type().Elem().Kind() {
+			case reflect.Struct:
+				if !hasTaggedFields(val.Type().Elem()) {
+					return
+				}
+				val.Set(reflect.New(val.Type().Elem()))
+			case reflect.Map, reflect.Slice, reflect.Pointer:
+				val.Set(reflect.New(val.Type().Elem()))
+			default:
 				return
 			}
-			val.Set(reflect.New(val.Type().Elem()))
-		case reflect.Map, reflect.Slice, reflect.Ptr:
-			val.Set(reflect.New(val.Type().Elem()))
-		default:
-			return
-		}
 		}
 		ptr := val.Pointer()
-		if visited[ptr] {
+		if w.visited[ptr] {
 			return
 		}
-		visited[ptr] = true
-		applyRecursive(data, val.Elem(), visited, errs)
+		w.visited[ptr] = true
+		w.apply(val.Elem(), path, prefix)
 
 	case reflect.Struct:
-		t := val.Type()
-		for i := 0; i < val.NumField(); i++ {
-			f := t.Field(i)
-			if !f.IsExported() {
-				continue
-			}
-			fv := val.Field(i)
-
-			envKey := f.Tag.Get("env")
-			defVal := f.Tag.Get("default")
-			if def := env.Or(envKey, defVal); def != "" {
-				if err := applyDefault(fv, def, data); err != nil {
-					*errs = append(*errs, fmt.Errorf("%s: %w", f.Name, err))
-				}
-			}
-
-			// fv is already addressable/settable whenever val (the parent
-			// struct) is - no .Addr()/re-dereference wrapping needed. If fv
-			// is itself pointer-typed, it lands in the Pointer case above on
-			// its own terms, with its own genuine target address.
-			applyRecursive(data, fv, visited, errs)
-		}
+		w.applyStruct(val, path, prefix)
 
 	case reflect.Slice, reflect.Array:
 		// Slice elements are always addressable regardless of whether the
 		// slice header itself is addressable (they live in the backing
 		// array); array elements are addressable iff the array is. Either
-		// way, no synthetic pointer wrapping is needed here.
+		// way, no synthetic pointer wrapping is needed here. Under an env
+		// prefix each element gets its own, e.g. `DB_0_` and `DB_1_`.
 		for i := 0; i < val.Len(); i++ {
-			applyRecursive(data, val.Index(i), visited, errs)
+			elemPrefix := prefix
+			if prefix != "" {
+				elemPrefix = fmt.Sprintf("%s%d_", prefix, i)
+			}
+			w.apply(val.Index(i), fmt.Sprintf("%s[%d]", path, i), elemPrefix)
 		}
 
 	case reflect.Map:
@@ -127,12 +155,91 @@ func applyRecursive(data any, val reflect.Value, visited map[uintptr]bool, errs
 			// existing address.
 			copyVal := reflect.New(elemType).Elem()
 			copyVal.Set(val.MapIndex(key))
-			applyRecursive(data, copyVal, visited, errs)
+			w.apply(copyVal, fmt.Sprintf("%s[%v]", path, key.Interface()), prefix)
 			val.SetMapIndex(key, copyVal)
 		}
+	default:
 	}
 }
 
+// applyStruct fills the fields of a struct in a fixed order:
+//  1. `env` and `default` tags that do not reference the struct (see [Scope]),
+//  2. `default` tags referencing `.Self`, `.Parent` or `.Root`, which see the values set in step 1,
+//  3. nested values, recursively in this same order,
+//  4. the struct's own [Defaulter] or [ContextDefaulter] method, which sees every tag default.
+func (w *walker) applyStruct(val reflect.Value, path string, prefix string) {
+	t := val.Type()
+	w.scopes = append(w.scopes, val)
+	defer func() { w.scopes = w.scopes[:len(w.scopes)-1] }()
+
+	for _, scoped := range []bool{false, true} {
+		for i := 0; i < val.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() || isScoped(f.Tag.Get("default")) != scoped {
+				continue
+			}
+			fieldPath := joinPath(path, f.Name)
+			if err := w.applyField(val.Field(i), f, fieldPath, prefix); err != nil {
+				w.errs = append(w.errs, fmt.Errorf("%s: %w", fieldPath, err))
+			}
+		}
+	}
+
+	for i := 0; i < val.NumField(); i++ {
+		if f := t.Field(i); f.IsExported() {
+			// val.Field(i) is already addressable/settable whenever val (the
+			// parent struct) is - no .Addr()/re-dereference wrapping needed.
+			// If it is itself pointer-typed, it lands in the Pointer case of
+			// apply on its own terms, with its own genuine target address.
+			w.apply(val.Field(i), joinPath(path, f.Name), prefix+f.Tag.Get("envPrefix"))
+		}
+	}
+
+	if err := w.callDefaulter(val, path); err != nil {
+		w.errs = append(w.errs, fmt.Errorf("%s: %w", pathOrRoot(path), err))
+	}
+}
+
+// applyField resolves the default of a single field from its `env` and
+// `default` tags and applies it.
+func (w *walker) applyField(field reflect.Value, f reflect.StructField, path string, prefix string) error {
+	envKey := f.Tag.Get("env")
+	def := f.Tag.Get("default")
+	source := SourceDefault
+	if envKey != "" {
+		envKey = prefix + envKey
+		if value := os.Getenv(envKey); value != "" {
+			def, source = value, SourceEnv
+		}
+	}
+	if def == "" {
+		return nil
+	}
+	data := w.data
+	if source == SourceDefault && isScoped(def) {
+		data = w.scope()
+	}
+	applied, err := applyDefault(field, def, data)
+	if err != nil || !applied {
+		return err
+	}
+	if w.report != nil {
+		if source == SourceDefault && isTemplate(def) {
+			source = SourceTemplate
+		}
+		if source != SourceEnv {
+			envKey = ""
+		}
+		*w.report = append(*w.report, Applied{
+			Path:   path,
+			Value:  field.Interface(),
+			Source: source,
+			Env:    envKey,
+		})
+	}
+	return nil
+}
+
 // hasTaggedFields reports whether t (expected to be a struct type) contains
 // at least one exported field carrying a default or env tag.
 func hasTaggedFields(t reflect.Type) bool {
@@ -149,12 +256,12 @@ func hasTaggedFields(t reflect.Type) bool {
 }
 
 // applyDefault decodes def (after template evaluation) into field, but only
-// if field is still at its zero value. Errors from decoding are returned
-// rather than swallowed, except for the plain-string fallback case, which
-// always succeeds.
-func applyDefault(field reflect.Value, def string, data any) error {
+// if field is still at its zero value, reporting whether it did. Errors from
+// decoding are returned rather than swallowed, except for the plain-string
+// fallback case, which always succeeds.
+func applyDefault(field reflect.Value, def string, data any) (bool, error) {
 	if !field.CanSet() || !field.IsZero() {
-		return nil
+		return false, nil
 	}
 
 	defValue, err := template.EvaluateTemplate(def, data)
@@ -165,15 +272,33 @@ func applyDefault(field reflect.Value, def string, data any) error {
 	newValue := reflect.New(field.Type())
 	if decodeErr := decoder.Decode(newValue.Interface(), defValue); decodeErr == nil {
 		field.Set(newValue.Elem())
-		return nil
+		return true, nil
 	} else if field.Kind() == reflect.String {
 		// decoder.Decode can fail for plain/named string types depending on
 		// how it's configured; falling back to a direct set still respects
 		// named string types via Convert, avoiding a reflect panic on type
 		// mismatch (e.g. type Foo string vs the raw `string` defValue).
 		field.Set(reflect.ValueOf(defValue).Convert(field.Type()))
-		return nil
+		return true, nil
 	} else {
-		return fmt.Errorf("failed to decode default value %q into %s: %w", def, field.Type(), decodeErr)
+		return false, fmt.Errorf("failed to decode default value %q into %s: %w", def, field.Type(), decodeErr)
+	}
+}
+
+func isTemplate(def string) bool {
+	return strings.Contains(def, "{{")
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
 	}
+	return path + "." + name
 }
@@ -1,6 +1,8 @@
 package defaulter_test
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -383,3 +385,139 @@ func TestApplyDefaults_NonPointerInputDoesNotPanic(t *testing.T) {
 	// no-op (nothing settable), not an error - just confirming no panic.
 	_ = defaulter.ApplyDefaults(v, nil)
 }
+
+// --- application report -------------------------------------------------------------------
+
+type reported struct {
+	Name     string `default:"anon"`
+	Greeting string `default:"hello-{{.Env}}"`
+	Value    string `default:"fallback" env:"DEFAULTER_TEST_VALUE"`
+	Set      int    `default:"5"`
+	Routes   []struct {
+		Timeout time.Duration `default:"30s"`
+	}
+}
+
+func TestApplyDefaults_Report(t *testing.T) {
+	t.Setenv("DEFAULTER_TEST_VALUE", "from-env")
+	v := &reported{Set: 1}
+	v.Routes = make([]struct {
+		Timeout time.Duration `default:"30s"`
+	}, 1)
+	var report defaulter.Report
+	if err := defaulter.ApplyDefaults(v, templateData{Env: "prod"}, defaulter.WithReport(&report)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []defaulter.Applied{
+		{Path: "Name", Value: "anon", Source: defaulter.SourceDefault},
+		{Path: "Greeting", Value: "hello-prod", Source: defaulter.SourceTemplate},
+		{Path: "Value", Value: "from-env", Source: defaulter.SourceEnv, Env: "DEFAULTER_TEST_VALUE"},
+		{Path: "Routes[0].Timeout", Value: 30 * time.Second, Source: defaulter.SourceDefault},
+	}
+	if len(report) != len(want) {
+		t.Fatalf("report = %+v, want %+v", report, want)
+	}
+	for i := range want {
+		if report[i] != want[i] {
+			t.Errorf("report[%d] = %+v, want %+v", i, report[i], want[i])
+		}
+	}
+	if _, ok := report.Lookup("Set"); ok {
+		t.Error("Set was already set and must not be reported")
+	}
+	if applied, ok := report.Lookup("Routes[0].Timeout"); !ok || applied.Value != 30*time.Second {
+		t.Errorf("Lookup(Routes[0].Timeout) = %+v, %v", applied, ok)
+	}
+}
+
+// --- Defaulter methods and scoped templates ------------------------------------------------
+
+type computedServer struct {
+	ReadTimeout time.Duration `default:"{{ .Self.Timeout }}"`
+	Timeout     time.Duration `default:"30s"`
+	Name        string        `default:"{{ .Parent.Prefix }}-{{ .Data.Env }}"`
+	Workers     int
+}
+
+func (s *computedServer) SetDefaults() {
+	if s.Workers == 0 {
+		s.Workers = int(s.Timeout / time.Second)
+	}
+}
+
+type computedRoot struct {
+	Server computedServer
+	Prefix string `default:"api"`
+}
+
+func TestApplyDefaults_ScopedTemplatesAndSetDefaults(t *testing.T) {
+	v := &computedRoot{}
+	var report defaulter.Report
+	if err := defaulter.ApplyDefaults(v, templateData{Env: "prod"}, defaulter.WithReport(&report)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Server.ReadTimeout != 30*time.Second {
+		t.Errorf("ReadTimeout = %v, want 30s (sibling reference)", v.Server.ReadTimeout)
+	}
+	if v.Server.Name != "api-prod" {
+		t.Errorf("Name = %q, want %q (parent reference)", v.Server.Name, "api-prod")
+	}
+	if v.Server.Workers != 30 {
+		t.Errorf("Workers = %d, want 30 (SetDefaults runs after tag defaults)", v.Server.Workers)
+	}
+	if applied, ok := report.Lookup("Server.Workers"); !ok || applied.Source != defaulter.SourceMethod {
+		t.Errorf("Lookup(Server.Workers) = %+v, %v", applied, ok)
+	}
+}
+
+type failingDefaults struct {
+	Port int `default:"80"`
+}
+
+func (f *failingDefaults) Defaults(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func TestApplyDefaults_ContextDefaulterError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	v := &failingDefaults{}
+	err := defaulter.ApplyDefaults(v, nil, defaulter.WithContext(ctx))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if v.Port != 80 {
+		t.Errorf("Port = %d, want 80", v.Port)
+	}
+}
+
+// --- env prefixes ----------------------------------------------------------------------------
+
+type prefixedDB struct {
+	Host string `default:"localhost" env:"HOST"`
+}
+
+type prefixedConfig struct {
+	Primary  prefixedDB   `envPrefix:"PRIMARY_"`
+	Replica  *prefixedDB  `envPrefix:"REPLICA_"`
+	Backends []prefixedDB `envPrefix:"BACKEND_"`
+}
+
+func TestApplyDefaults_EnvPrefixes(t *testing.T) {
+	t.Setenv("APP_PRIMARY_HOST", "primary.db")
+	t.Setenv("APP_REPLICA_HOST", "replica.db")
+	t.Setenv("APP_BACKEND_1_HOST", "backend1.db")
+	v := &prefixedConfig{Backends: make([]prefixedDB, 2)}
+	if err := defaulter.ApplyDefaults(v, nil, defaulter.WithEnvPrefix("APP_")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Primary.Host != "primary.db" {
+		t.Errorf("Primary.Host = %q, want %q", v.Primary.Host, "primary.db")
+	}
+	if v.Replica == nil || v.Replica.Host != "replica.db" {
+		t.Errorf("Replica = %+v, want host %q", v.Replica, "replica.db")
+	}
+	if v.Backends[0].Host != "localhost" || v.Backends[1].Host != "backend1.db" {
+		t.Errorf("Backends = %+v, want localhost and backend1.db", v.Backends)
+	}
+}
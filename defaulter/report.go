@@ -0,0 +1,66 @@
+package defaulter
+
+import (
+	"go.uber.org/zap"
+)
+
+// Source names where an applied default came from.
+type Source string
+
+const (
+	// SourceEnv is a value read from the variable named by the `env` tag.
+	SourceEnv Source = "env"
+	// SourceDefault is the static value of the `default` tag.
+	SourceDefault Source = "default"
+	// SourceTemplate is the `default` tag evaluated as a template.
+	SourceTemplate Source = "template"
+	// SourceMethod is a value set by a [Defaulter] or [ContextDefaulter] method.
+	SourceMethod Source = "method"
+)
+
+// Applied describes a single field filled by [ApplyDefaults].
+type Applied struct {
+	// Path of the field from the root value, e.g. `Server.Routes[0].Timeout`.
+	Path string
+	// Value set on the field.
+	Value any
+	// Source of the value.
+	Source Source
+	// Env is the variable the value was read from, set for [SourceEnv] only.
+	Env string
+}
+
+// Report lists the fields filled by [ApplyDefaults], in the order they were applied.
+type Report []Applied
+
+// WithReport appends every field filled by [ApplyDefaults] to report.
+func WithReport(report *Report) Option {
+	return func(w *walker) {
+		w.report = report
+	}
+}
+
+// Lookup returns the entry of the field at path, answering why a field holds its value.
+func (r Report) Lookup(path string) (Applied, bool) {
+	for _, applied := range r {
+		if applied.Path == path {
+			return applied, true
+		}
+	}
+	return Applied{}, false
+}
+
+// Log writes one info entry per applied default to logger.
+func (r Report) Log(logger *zap.Logger) {
+	for _, applied := range r {
+		fields := []zap.Field{
+			zap.String("path", applied.Path),
+			zap.Any("value", applied.Value),
+			zap.String("source", string(applied.Source)),
+		}
+		if applied.Env != "" {
+			fields = append(fields, zap.String("env", applied.Env))
+		}
+		logger.Info("default applied", fields...)
+	}
+}
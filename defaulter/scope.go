@@ -0,0 +1,100 @@
+package defaulter
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+)
+
+// Defaulter is implemented by structs computing defaults tags cannot express,
+// e.g. `Workers` defaulting to twice the CPU count. [ApplyDefaults] calls it
+// after the tag defaults of the struct and of every value nested in it.
+type Defaulter interface {
+	SetDefaults()
+}
+
+// ContextDefaulter is the fallible, context aware variant of [Defaulter], receiving
+// the context given by [WithContext]. It takes precedence when a struct implements both.
+type ContextDefaulter interface {
+	Defaults(ctx context.Context) error
+}
+
+// Scope is the template data of `default` tags referencing `.Self`, `.Parent` or `.Root`,
+// such as `default:"{{ .Self.Timeout }}"`. Other tags are evaluated against the data
+// given to [ApplyDefaults] directly, which Scope exposes as Data.
+type Scope struct {
+	// Self is the struct holding the field.
+	Self any
+	// Parent is the struct holding Self, nil at the root.
+	Parent any
+	// Root is the outermost struct.
+	Root any
+	// Data is the data given to [ApplyDefaults].
+	Data any
+}
+
+var scopeRef = regexp.MustCompile(`\.(Self|Parent|Root)\b`)
+
+// isScoped reports whether def references the struct being filled.
+func isScoped(def string) bool {
+	return isTemplate(def) && scopeRef.MatchString(def)
+}
+
+func (w *walker) scope() Scope {
+	ref := func(i int) any {
+		if i < 0 || i >= len(w.scopes) {
+			return nil
+		}
+		val := w.scopes[i]
+		if val.CanAddr() {
+			return val.Addr().Interface()
+		}
+		return val.Interface()
+	}
+	last := len(w.scopes) - 1
+	return Scope{
+		Self:   ref(last),
+		Parent: ref(last - 1),
+		Root:   ref(0),
+		Data:   w.data,
+	}
+}
+
+// callDefaulter runs the [ContextDefaulter] or [Defaulter] method of the struct val,
+// reporting the fields it filled.
+func (w *walker) callDefaulter(val reflect.Value, path string) error {
+	target := val.Interface()
+	if val.CanAddr() {
+		target = val.Addr().Interface()
+	}
+	var before reflect.Value
+	if w.report != nil {
+		before = reflect.New(val.Type()).Elem()
+		before.Set(val)
+	}
+	switch d := target.(type) {
+	case ContextDefaulter:
+		if err := d.Defaults(w.ctx); err != nil {
+			return err
+		}
+	case Defaulter:
+		d.SetDefaults()
+	default:
+		return nil
+	}
+	if w.report != nil {
+		t := val.Type()
+		for i := 0; i < val.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() || !before.Field(i).IsZero() || val.Field(i).IsZero() {
+				continue
+			}
+			*w.report = append(*w.report, Applied{
+				Path:   joinPath(path, f.Name),
+				Value:  val.Field(i).Interface(),
+				Source: SourceMethod,
+			})
+		}
+	}
+	return nil
+}
@@ -10,7 +10,7 @@ type contextKey string
 
 const loggerKey contextKey = "zap-logger"
 
-// WithNewLogger builds logger and attach it to given context.
+// WithNewLogger builds logger and attach it to given context, the fields added to ctx by [WithFields] are applied to it.
 func WithNewLogger(
 	ctx context.Context,
 	builders ...BuilderFunc,
@@ -76,15 +76,18 @@ func WithNewEnvLoggerForced(
 	)
 }
 
-// WithLogger adds logger to context.
+// WithLogger adds logger to context, the fields added to ctx by [WithFields] are applied to it by [FromContext].
+// A logger derived from [FromContext], e.g. `WithLogger(ctx, FromContext(ctx).Named("db"))`, only gets
+// the fields it does not carry yet.
 func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
-	return context.WithValue(ctx, loggerKey, logger)
+	return context.WithValue(ctx, loggerKey, contextLogger{logger: logger, fields: carriedFields(logger)})
 }
 
-// FromContext extracts logger from context, or returns a nop logger if it was not found.
+// FromContext extracts logger from context with the fields added by [WithFields], or returns a nop logger
+// if it was not found.
 func FromContext(ctx context.Context) *zap.Logger {
-	if logger, ok := ctx.Value(loggerKey).(*zap.Logger); ok {
-		return logger
+	if logger, ok := ctx.Value(loggerKey).(contextLogger); ok {
+		return logger.withFields(ctx)
 	}
 	return zap.NewNop()
 }
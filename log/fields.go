@@ -0,0 +1,172 @@
+package log
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const fieldsKey contextKey = "zap-fields"
+
+// Keys of the tracing fields, see WithTrace.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// contextFields is a node of the fields added to a context, linked to the fields added before.
+type contextFields struct {
+	parent *contextFields
+	fields []zap.Field
+	// cached is the logger of the context the node was added to with the fields applied.
+	cached atomic.Pointer[cachedLogger]
+}
+
+type cachedLogger struct {
+	base   *zap.Logger
+	logger *zap.Logger
+}
+
+// contextLogger is a logger stored in a context, fields holds the context fields the logger carries already.
+type contextLogger struct {
+	logger *zap.Logger
+	fields *contextFields
+}
+
+// fieldsCore marks the core of the loggers returned by FromContext with the context fields they carry,
+// it is kept by the loggers derived from them with Named or With.
+type fieldsCore struct {
+	zapcore.Core
+	fields *contextFields
+}
+
+func (c *fieldsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &fieldsCore{Core: c.Core.With(fields), fields: c.fields}
+}
+
+// carriedFields returns the context fields logger carries, nil if it was not derived from FromContext.
+func carriedFields(logger *zap.Logger) *contextFields {
+	if logger == nil {
+		return nil
+	}
+	if core, ok := logger.Core().(*fieldsCore); ok {
+		return core.fields
+	}
+	return nil
+}
+
+// WithFields adds fields to the logger of ctx, they are applied when it is retrieved by FromContext.
+// A later field replaces an earlier one with the same key, except for a field a logger stored with
+// WithLogger already carries: both are logged then.
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, fieldsKey, &contextFields{
+		parent: fieldsOf(ctx),
+		fields: slices.Clone(fields),
+	})
+}
+
+// Fields returns the fields added to ctx by WithFields, oldest first, e.g. to propagate them.
+func Fields(ctx context.Context) []zap.Field {
+	return collectFields(fieldsOf(ctx), nil)
+}
+
+// FieldMap returns the fields added to ctx by WithFields as plain values by key.
+func FieldMap(ctx context.Context) map[string]any {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range Fields(ctx) {
+		field.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+// TraceID returns the field holding a trace id.
+func TraceID(id string) zap.Field {
+	return zap.String(TraceIDKey, id)
+}
+
+// SpanID returns the field holding a span id.
+func SpanID(id string) zap.Field {
+	return zap.String(SpanIDKey, id)
+}
+
+// WithTrace adds the trace and span id fields to ctx, empty ids are left out.
+func WithTrace(ctx context.Context, traceID, spanID string) context.Context {
+	var fields []zap.Field
+	if traceID != "" {
+		fields = append(fields, TraceID(traceID))
+	}
+	if spanID != "" {
+		fields = append(fields, SpanID(spanID))
+	}
+	return WithFields(ctx, fields...)
+}
+
+// TraceFrom returns the trace and span ids added to ctx by WithTrace, empty if there are none.
+func TraceFrom(ctx context.Context) (traceID, spanID string) {
+	for node := fieldsOf(ctx); node != nil && (traceID == "" || spanID == ""); node = node.parent {
+		for i := len(node.fields) - 1; i >= 0; i-- {
+			field := node.fields[i]
+			if field.Type != zapcore.StringType {
+				continue
+			}
+			switch {
+			case field.Key == TraceIDKey && traceID == "":
+				traceID = field.String
+			case field.Key == SpanIDKey && spanID == "":
+				spanID = field.String
+			}
+		}
+	}
+	return traceID, spanID
+}
+
+func fieldsOf(ctx context.Context) *contextFields {
+	if fields, ok := ctx.Value(fieldsKey).(*contextFields); ok {
+		return fields
+	}
+	return nil
+}
+
+// collectFields returns the fields from node up to, not including, stop, oldest first and
+// without the ones replaced by a later field with the same key.
+func collectFields(node, stop *contextFields) []zap.Field {
+	seen := make(map[string]struct{})
+	fields := make([]zap.Field, 0)
+	for ; node != nil && node != stop; node = node.parent {
+		for i := len(node.fields) - 1; i >= 0; i-- {
+			if _, ok := seen[node.fields[i].Key]; ok {
+				continue
+			}
+			seen[node.fields[i].Key] = struct{}{}
+			fields = append(fields, node.fields[i])
+		}
+	}
+	slices.Reverse(fields)
+	return fields
+}
+
+// withFields applies the fields of ctx the logger does not carry, the returned logger is marked as carrying all of them.
+func (c contextLogger) withFields(ctx context.Context) *zap.Logger {
+	node := fieldsOf(ctx)
+	if node == nil || node == c.fields || c.logger == nil {
+		return c.logger
+	}
+	if cached := node.cached.Load(); cached != nil && cached.base == c.logger {
+		return cached.logger
+	}
+	logger := c.logger.With(collectFields(node, c.fields)...).
+		WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			if tagged, ok := core.(*fieldsCore); ok {
+				core = tagged.Core
+			}
+			return &fieldsCore{Core: core, fields: node}
+		}))
+	node.cached.Store(&cachedLogger{base: c.logger, logger: logger})
+	return logger
+}
@@ -0,0 +1,99 @@
+package log_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fmotalleb/go-tools/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithFields(t *testing.T) {
+	logger, logs := log.NewObserved(zapcore.DebugLevel)
+	ctx := log.WithLogger(context.Background(), logger)
+	ctx = log.WithFields(ctx, zap.String("request_id", "r-1"))
+	ctx = log.WithTrace(ctx, "t-1", "s-1")
+
+	log.FromContext(ctx).Info("handled")
+	logs.AssertLogged(t, zapcore.InfoLevel, "handled",
+		zap.String("request_id", "r-1"), log.TraceID("t-1"), log.SpanID("s-1"))
+
+	// A logger derived from the context already carries its fields.
+	ctx = log.WithLogger(ctx, log.Of(ctx).Named("db"))
+	ctx = log.WithTrace(ctx, "", "s-2")
+	log.Of(ctx).Info("query")
+	logs.AssertLogged(t, zapcore.InfoLevel, "query", zap.String("request_id", "r-1"), log.SpanID("s-2"))
+
+	if trace, span := log.TraceFrom(ctx); trace != "t-1" || span != "s-2" {
+		t.Errorf("TraceFrom = %q, %q; want t-1, s-2", trace, span)
+	}
+	want := map[string]any{"request_id": "r-1", log.TraceIDKey: "t-1", log.SpanIDKey: "s-2"}
+	got := log.FieldMap(ctx)
+	if len(got) != len(want) {
+		t.Fatalf("FieldMap = %v, want %v", got, want)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("FieldMap[%q] = %v, want %v", key, got[key], value)
+		}
+	}
+	if fields := log.Fields(ctx); len(fields) != 3 || fields[2].Key != log.SpanIDKey {
+		t.Errorf("Fields = %v", fields)
+	}
+}
+
+func TestWithLoggerAppliesEarlierFields(t *testing.T) {
+	ctx := log.WithFields(context.Background(), zap.String("request_id", "r-1"))
+	logger, logs := log.NewObserved(zapcore.DebugLevel)
+	ctx = log.WithLogger(ctx, logger)
+	log.FromContext(ctx).Info("handled")
+	logs.AssertLogged(t, zapcore.InfoLevel, "handled", zap.String("request_id", "r-1"))
+
+	// A field replaced after a derived logger was stored is logged with both values.
+	ctx = log.WithLogger(ctx, log.Of(ctx).Named("db"))
+	ctx = log.WithFields(ctx, zap.String("request_id", "r-2"))
+	log.Of(ctx).Info("query")
+	entries := logs.FilterMessage("query").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	var ids []string
+	for _, field := range entries[0].Context {
+		if field.Key == "request_id" {
+			ids = append(ids, field.String)
+		}
+	}
+	if len(ids) != 2 || ids[0] != "r-1" || ids[1] != "r-2" {
+		t.Errorf("request_id values = %v, want [r-1 r-2]", ids)
+	}
+}
+
+func TestWithNewLoggerKeepsFields(t *testing.T) {
+	ctx := log.WithFields(context.Background(), zap.String("request_id", "r-1"))
+	ring := log.NewRing(0)
+	ctx, err := log.WithNewLogger(ctx, func(b *log.Builder) *log.Builder {
+		return b.Silent().Ring(ring)
+	})
+	if err != nil {
+		t.Fatalf("WithNewLogger failed: %v", err)
+	}
+	ctx = log.WithTrace(ctx, "t-1", "")
+	log.FromContext(ctx).Info("handled")
+
+	ctx = log.WithNewEnvLoggerForced(ctx, func(b *log.Builder) *log.Builder {
+		return b.Silent().Ring(ring)
+	})
+	log.FromContext(ctx).Info("replaced")
+
+	entries := ring.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	for _, entry := range entries {
+		fields := entry.ContextMap()
+		if fields["request_id"] != "r-1" || fields[log.TraceIDKey] != "t-1" || len(fields) != 2 {
+			t.Errorf("%s: fields = %v, want request_id and trace_id once", entry.Message, fields)
+		}
+	}
+}
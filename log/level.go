@@ -0,0 +1,180 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultLevelCycle is the order CycleOnSignal steps through, wrapping around after the last level.
+var DefaultLevelCycle = []zapcore.Level{
+	zapcore.DebugLevel,
+	zapcore.InfoLevel,
+	zapcore.WarnLevel,
+	zapcore.ErrorLevel,
+}
+
+// LevelController changes the level of running loggers and logs every change.
+type LevelController struct {
+	level  zap.AtomicLevel
+	logger *zap.Logger
+	cycle  []zapcore.Level
+}
+
+// NewLevelController creates a controller for level, reporting changes to logger (Default no-op),
+// use Builder.BuildWithLevel or Builder.AtomicLevel to obtain the level of a logger.
+func NewLevelController(level zap.AtomicLevel, logger *zap.Logger) *LevelController {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &LevelController{
+		level:  level,
+		logger: logger.Named("log-level"),
+		cycle:  DefaultLevelCycle,
+	}
+}
+
+// Level returns the current level.
+func (c *LevelController) Level() zapcore.Level {
+	return c.level.Level()
+}
+
+// Set changes the level, the change is logged at the new level (at least info, at most error).
+// The entry is checked against the previous level first, then the new one, so raising the level
+// past error is logged too.
+func (c *LevelController) Set(level zapcore.Level) {
+	from := c.level.Level()
+	if from == level {
+		return
+	}
+	at := min(max(level, zapcore.InfoLevel), zapcore.ErrorLevel)
+	ce := c.logger.Check(at, "log level changed")
+	c.level.SetLevel(level)
+	if ce == nil {
+		ce = c.logger.Check(at, "log level changed")
+	}
+	if ce != nil {
+		ce.Write(zap.Stringer("from", from), zap.Stringer("to", level))
+	}
+}
+
+// SetText parses level, e.g. `debug` or `WARN`, and changes to it.
+func (c *LevelController) SetText(level string) error {
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return errors.Join(errors.New("failed to change log level"), err)
+	}
+	c.Set(parsed)
+	return nil
+}
+
+// Next changes to the level after the current one in the cycle and returns it.
+func (c *LevelController) Next() zapcore.Level {
+	current := c.level.Level()
+	next := c.cycle[0]
+	for _, level := range c.cycle {
+		if level > current {
+			next = level
+			break
+		}
+	}
+	c.Set(next)
+	return next
+}
+
+type levelPayload struct {
+	Level string `json:"level"`
+}
+
+// ServeHTTP reports the level on GET and changes it on PUT or POST, the level is read from
+// a JSON body `{"level":"debug"}` or a `level` form/query value.
+func (c *LevelController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		level, err := requestedLevel(r)
+		if err == nil {
+			err = c.SetText(level)
+		}
+		if err != nil {
+			writeLevelResponse(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		writeLevelResponse(w, http.StatusMethodNotAllowed, map[string]string{
+			"error": "method " + r.Method + " not allowed",
+		})
+		return
+	}
+	writeLevelResponse(w, http.StatusOK, levelPayload{Level: c.level.Level().String()})
+}
+
+func requestedLevel(r *http.Request) (string, error) {
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil && mediaType == "application/json" {
+		var payload levelPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			return "", fmt.Errorf("failed to decode request body: %w", err)
+		}
+		return payload.Level, nil
+	}
+	if level := r.FormValue("level"); level != "" {
+		return level, nil
+	}
+	return "", errors.New("missing level")
+}
+
+func writeLevelResponse(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// CycleOnSignal steps to the next level of the cycle on each signal until ctx is done.
+// Signals default to DefaultLevelSignals (SIGUSR2 on unix), it returns at once if there are none.
+func (c *LevelController) CycleOnSignal(ctx context.Context, signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = DefaultLevelSignals
+	}
+	if len(signals) == 0 {
+		return
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, signals...)
+	defer signal.Stop(sig)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sig:
+			c.Next()
+		}
+	}
+}
+
+// Watch applies every level received from values, e.g. the level field of a configuration
+// reloaded by the reloader package, until ctx is done or values is closed.
+// Invalid levels are logged and ignored.
+func (c *LevelController) Watch(ctx context.Context, values <-chan string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case value, ok := <-values:
+			if !ok {
+				return
+			}
+			if err := c.SetText(value); err != nil {
+				c.logger.Warn("ignored invalid log level", zap.String("level", value), zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,12 @@
+//go:build unix
+
+package log
+
+import (
+	"os"
+	"syscall"
+)
+
+var DefaultLevelSignals = []os.Signal{
+	syscall.SIGUSR2,
+}
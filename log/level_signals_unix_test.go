@@ -0,0 +1,54 @@
+//go:build unix
+
+package log_test
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fmotalleb/go-tools/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelControllerCycleOnSignal(t *testing.T) {
+	// Keep SIGUSR2 from terminating the test binary before CycleOnSignal listens to it.
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGUSR2)
+	defer signal.Stop(guard)
+
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	ctl := log.NewLevelController(level, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ctl.CycleOnSignal(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(time.Second)
+	for level.Level() == zapcore.InfoLevel {
+		if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR2); err != nil {
+			t.Fatalf("failed to send signal: %v", err)
+		}
+		select {
+		case <-deadline:
+			t.Fatal("level did not change on SIGUSR2")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if got := level.Level(); got != zapcore.WarnLevel && got != zapcore.ErrorLevel {
+		t.Errorf("level = %v, want the next levels of the cycle", got)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("CycleOnSignal did not return after cancel")
+	}
+}
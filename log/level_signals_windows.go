@@ -0,0 +1,9 @@
+//go:build windows
+
+package log
+
+import (
+	"os"
+)
+
+var DefaultLevelSignals = []os.Signal{}
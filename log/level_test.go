@@ -0,0 +1,124 @@
+package log_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fmotalleb/go-tools/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLevelController(t *testing.T) {
+	logger, level, err := log.NewBuilder().Silent().Level("info").BuildWithLevel()
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if logger.Core().Enabled(zapcore.DebugLevel) {
+		t.Fatal("debug enabled before change")
+	}
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctl := log.NewLevelController(level, zap.New(core))
+
+	if err := ctl.SetText("debug"); err != nil {
+		t.Fatalf("SetText failed: %v", err)
+	}
+	if !logger.Core().Enabled(zapcore.DebugLevel) {
+		t.Error("debug not enabled after change")
+	}
+	changes := logs.FilterMessage("log level changed").All()
+	if len(changes) != 1 || changes[0].ContextMap()["from"] != "info" || changes[0].ContextMap()["to"] != "debug" {
+		t.Errorf("unexpected change log: %v", changes)
+	}
+	if err := ctl.SetText("loud"); err == nil {
+		t.Error("expected error for invalid level")
+	}
+	if got := ctl.Next(); got != zapcore.InfoLevel {
+		t.Errorf("Next() = %v, want info", got)
+	}
+	ctl.Set(zapcore.ErrorLevel)
+	if got := ctl.Next(); got != zapcore.DebugLevel {
+		t.Errorf("Next() after error = %v, want debug", got)
+	}
+}
+
+func TestLevelControllerLogsThroughOwnLevel(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zapcore.WarnLevel)
+	core, logs := observer.New(level)
+	ctl := log.NewLevelController(level, zap.New(core))
+
+	ctl.Set(zapcore.PanicLevel)
+	ctl.Set(zapcore.DebugLevel)
+	changes := logs.FilterMessage("log level changed").All()
+	if len(changes) != 2 {
+		t.Fatalf("got %d change logs, want 2: %v", len(changes), changes)
+	}
+	if changes[0].ContextMap()["to"] != "panic" || changes[0].Level != zapcore.ErrorLevel {
+		t.Errorf("unexpected raise log: %v", changes[0])
+	}
+	if changes[1].ContextMap()["to"] != "debug" || changes[1].Level != zapcore.InfoLevel {
+		t.Errorf("unexpected lower log: %v", changes[1])
+	}
+}
+
+func TestLevelControllerHTTP(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	ctl := log.NewLevelController(level, nil)
+
+	rec := httptest.NewRecorder()
+	ctl.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"info"`) {
+		t.Errorf("GET = %d %s", rec.Code, rec.Body)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"warn"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	ctl.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || level.Level() != zapcore.WarnLevel {
+		t.Errorf("PUT json = %d %s, level %v", rec.Code, rec.Body, level.Level())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"level":"error"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	rec = httptest.NewRecorder()
+	ctl.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || level.Level() != zapcore.ErrorLevel {
+		t.Errorf("POST json with charset = %d %s, level %v", rec.Code, rec.Body, level.Level())
+	}
+
+	rec = httptest.NewRecorder()
+	ctl.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/?level=nope", nil))
+	if rec.Code != http.StatusBadRequest || level.Level() != zapcore.ErrorLevel {
+		t.Errorf("POST invalid = %d %s, level %v", rec.Code, rec.Body, level.Level())
+	}
+}
+
+func TestLevelControllerWatch(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	logger := log.NewBuilder().Silent().AtomicLevel(level).MustBuild()
+	ctl := log.NewLevelController(level, logger)
+
+	values := make(chan string)
+	done := make(chan struct{})
+	go func() {
+		ctl.Watch(context.Background(), values)
+		close(done)
+	}()
+	values <- "error"
+	values <- "bogus"
+	close(values)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Watch did not return after close")
+	}
+	if level.Level() != zapcore.ErrorLevel {
+		t.Errorf("level = %v, want error", level.Level())
+	}
+}
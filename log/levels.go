@@ -0,0 +1,172 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fmotalleb/go-tools/matcher"
+	"go.uber.org/zap/zapcore"
+)
+
+// levelCacheSize bounds the logger names a [LevelSpec] remembers, names past it are matched on every check.
+const levelCacheSize = 4096
+
+// LevelSpec holds per logger name levels, parsed from a spec like `info,config-reader=debug,broadcast=warn`.
+type LevelSpec struct {
+	// Default is the level of loggers matched by no rule, set by a bare level in the spec.
+	Default    zapcore.Level
+	HasDefault bool
+	rules      []levelRule
+	// lowest is the lowest level of any rule.
+	lowest zapcore.Level
+	// cache maps logger names to their *zapcore.Level, nil when no rule matches.
+	cache  sync.Map
+	cached atomic.Int64
+}
+
+type levelRule struct {
+	pattern string
+	matcher *matcher.Matcher
+	level   zapcore.Level
+}
+
+// ParseLevelSpec parses a comma separated list of `pattern=level` rules and an optional bare default level.
+// Patterns are matched against the logger name and each of its parents (`a.b` for `a.b.c`), so a rule
+// covers the named subtree, the most specific name wins and among rules for one name the last one.
+// Patterns are `matcher` patterns, a wildcard by default (`http-*`, `*.broadcast`, `*` spans dots)
+// or `kind:pattern`.
+// Names are compared in lower case, so `broadcast=warn` matches the `Broadcast` logger.
+func ParseLevelSpec(spec string) (*LevelSpec, error) {
+	s := &LevelSpec{Default: DefaultLevel, lowest: zapcore.InvalidLevel}
+	for item := range strings.SplitSeq(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pattern, levelText, hasPattern := strings.Cut(item, "=")
+		if !hasPattern {
+			levelText = pattern
+		}
+		level, err := zapcore.ParseLevel(strings.TrimSpace(levelText))
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to parse level spec %q", spec), err)
+		}
+		if !hasPattern {
+			s.Default, s.HasDefault = level, true
+			continue
+		}
+		pattern = strings.TrimSpace(pattern)
+		if !strings.Contains(pattern, ":") {
+			pattern = strings.ToLower(pattern)
+		}
+		m := new(matcher.Matcher)
+		if _, err := m.Decode(nil, pattern); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to parse level spec %q: invalid pattern %q", spec, pattern), err)
+		}
+		s.rules = append(s.rules, levelRule{pattern: pattern, matcher: m, level: level})
+		if s.lowest == zapcore.InvalidLevel || level < s.lowest {
+			s.lowest = level
+		}
+	}
+	return s, nil
+}
+
+// LevelFor returns the level of the rule matching name, reporting false if no rule does.
+func (s *LevelSpec) LevelFor(name string) (zapcore.Level, bool) {
+	if len(s.rules) == 0 {
+		return s.Default, false
+	}
+	if cached, ok := s.cache.Load(name); ok {
+		level := cached.(*zapcore.Level)
+		if level == nil {
+			return s.Default, false
+		}
+		return *level, true
+	}
+	level := s.match(strings.ToLower(name))
+	if s.cached.Load() < levelCacheSize {
+		if _, loaded := s.cache.LoadOrStore(name, level); !loaded {
+			s.cached.Add(1)
+		}
+	}
+	if level == nil {
+		return s.Default, false
+	}
+	return *level, true
+}
+
+func (s *LevelSpec) match(name string) *zapcore.Level {
+	for name != "" {
+		for i := len(s.rules) - 1; i >= 0; i-- {
+			if s.rules[i].matcher.Match(name) {
+				return &s.rules[i].level
+			}
+		}
+		dot := strings.LastIndexByte(name, '.')
+		if dot < 0 {
+			break
+		}
+		name = name[:dot]
+	}
+	return nil
+}
+
+// String encodes the spec back to its text form.
+func (s *LevelSpec) String() string {
+	parts := make([]string, 0, len(s.rules)+1)
+	if s.HasDefault {
+		parts = append(parts, s.Default.String())
+	}
+	for _, rule := range s.rules {
+		parts = append(parts, rule.pattern+"="+rule.level.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// nameLevelCore enforces the levels of a LevelSpec, entries of loggers matched by no rule
+// are checked against base. The wrapped core must enable every level.
+type nameLevelCore struct {
+	zapcore.Core
+	base zapcore.LevelEnabler
+	spec *LevelSpec
+}
+
+// NewNameLevelCore wraps core so that entries are filtered by the level spec rules matching their
+// logger name and by base otherwise, core itself should enable all levels (e.g. zapcore.DebugLevel).
+func NewNameLevelCore(core zapcore.Core, base zapcore.LevelEnabler, spec *LevelSpec) zapcore.Core {
+	return &nameLevelCore{Core: core, base: base, spec: spec}
+}
+
+func (c *nameLevelCore) Enabled(level zapcore.Level) bool {
+	if c.base.Enabled(level) {
+		return true
+	}
+	return len(c.spec.rules) > 0 && level >= c.spec.lowest
+}
+
+// Level implements zapcore.LevelOf.
+func (c *nameLevelCore) Level() zapcore.Level {
+	level := zapcore.LevelOf(c.base)
+	if len(c.spec.rules) > 0 {
+		level = min(level, c.spec.lowest)
+	}
+	return level
+}
+
+func (c *nameLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &nameLevelCore{Core: c.Core.With(fields), base: c.base, spec: c.spec}
+}
+
+func (c *nameLevelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if level, ok := c.spec.LevelFor(entry.LoggerName); ok {
+		if entry.Level < level {
+			return checked
+		}
+	} else if !c.base.Enabled(entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
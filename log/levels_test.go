@@ -0,0 +1,91 @@
+package log_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/fmotalleb/go-tools/log"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestParseLevelSpec(t *testing.T) {
+	spec, err := log.ParseLevelSpec("warn, config-reader=debug, Broadcast=error, http-*=info, http-admin=debug")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if !spec.HasDefault || spec.Default != zapcore.WarnLevel {
+		t.Errorf("default = %v (%v), want warn", spec.Default, spec.HasDefault)
+	}
+	tests := []struct {
+		name  string
+		level zapcore.Level
+		ok    bool
+	}{
+		{"config-reader", zapcore.DebugLevel, true},
+		{"config-reader.parser", zapcore.DebugLevel, true},
+		{"app.broadcast", zapcore.WarnLevel, false},
+		{"broadcast.Subscribe", zapcore.ErrorLevel, true},
+		{"http-public", zapcore.InfoLevel, true},
+		{"http-admin", zapcore.DebugLevel, true},
+		{"reloader", zapcore.WarnLevel, false},
+	}
+	for _, tt := range tests {
+		level, ok := spec.LevelFor(tt.name)
+		if level != tt.level || ok != tt.ok {
+			t.Errorf("LevelFor(%q) = %v, %v; want %v, %v", tt.name, level, ok, tt.level, tt.ok)
+		}
+	}
+	// Names past the cache size are still matched.
+	for i := range 5000 {
+		if level, ok := spec.LevelFor("http-" + strconv.Itoa(i)); level != zapcore.InfoLevel || !ok {
+			t.Fatalf("LevelFor(http-%d) = %v, %v; want info, true", i, level, ok)
+		}
+	}
+	bare, err := log.ParseLevelSpec("debug")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if level, ok := bare.LevelFor("any"); level != zapcore.DebugLevel || ok {
+		t.Errorf("LevelFor without rules = %v, %v; want debug, false", level, ok)
+	}
+	if _, err := log.ParseLevelSpec("info,x=loud"); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
+
+func TestBuilderLevels(t *testing.T) {
+	var entries []zapcore.Entry
+	logger, level, err := log.NewBuilder().
+		Silent().
+		Levels("info,config-reader=debug,broadcast=warn").
+		AddHook(func(e zapcore.Entry) error {
+			entries = append(entries, e)
+			return nil
+		}).
+		BuildWithLevel()
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	logger.Debug("root debug")
+	logger.Info("root info")
+	logger.Named("config-reader").Debug("reader debug")
+	logger.Named("Broadcast").Info("broadcast info")
+	logger.Named("Broadcast").Warn("broadcast warn")
+
+	level.SetLevel(zapcore.DebugLevel)
+	logger.Debug("root debug after change")
+
+	want := []string{"root info", "reader debug", "broadcast warn", "root debug after change"}
+	if len(entries) != len(want) {
+		t.Fatalf("logged %d entries (%v), want %v", len(entries), entries, want)
+	}
+	for i, msg := range want {
+		if entries[i].Message != msg {
+			t.Errorf("entry %d = %q, want %q", i, entries[i].Message, msg)
+		}
+	}
+
+	if _, err := log.NewBuilder().Silent().Levels("x=loud").Build(); err == nil {
+		t.Error("expected error for invalid spec")
+	}
+}
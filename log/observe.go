@@ -0,0 +1,102 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TB is the part of testing.TB used by the assertions of Observed.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Observed records the entries of a logger created by NewObserved.
+type Observed struct {
+	*observer.ObservedLogs
+}
+
+// NewObserved creates a logger recording every entry at or above level, for use in tests
+// with WithLogger, and the recorded entries.
+func NewObserved(level zapcore.LevelEnabler) (*zap.Logger, *Observed) {
+	core, logs := observer.New(level)
+	return zap.New(core), &Observed{ObservedLogs: logs}
+}
+
+// Find returns the entries at level whose message contains msg and which carry all fields,
+// compared by key and value; an empty msg matches any message.
+func (o *Observed) Find(level zapcore.Level, msg string, fields ...zap.Field) []observer.LoggedEntry {
+	found := make([]observer.LoggedEntry, 0)
+	for _, entry := range o.All() {
+		if entry.Level == level && strings.Contains(entry.Message, msg) && hasFields(entry, fields) {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+// Logged reports whether an entry at level containing msg with all fields was logged.
+func (o *Observed) Logged(level zapcore.Level, msg string, fields ...zap.Field) bool {
+	return len(o.Find(level, msg, fields...)) > 0
+}
+
+// AssertLogged fails t unless an entry at level containing msg with all fields was logged.
+func (o *Observed) AssertLogged(t TB, level zapcore.Level, msg string, fields ...zap.Field) bool {
+	t.Helper()
+	if o.Logged(level, msg, fields...) {
+		return true
+	}
+	t.Errorf("no %s entry containing %q%s was logged, got:\n%s", level, msg, describeFields(fields), o)
+	return false
+}
+
+// AssertNotLogged fails t if an entry at level containing msg with all fields was logged.
+func (o *Observed) AssertNotLogged(t TB, level zapcore.Level, msg string, fields ...zap.Field) bool {
+	t.Helper()
+	found := o.Find(level, msg, fields...)
+	if len(found) == 0 {
+		return true
+	}
+	t.Errorf("unexpected %s entry containing %q%s was logged: %q", level, msg, describeFields(fields), found[0].Message)
+	return false
+}
+
+// String lists the recorded entries, one per line.
+func (o *Observed) String() string {
+	var sb strings.Builder
+	for _, entry := range o.All() {
+		fmt.Fprintf(&sb, "  %s %q %v\n", entry.Level, entry.Message, entry.ContextMap())
+	}
+	return sb.String()
+}
+
+func hasFields(entry observer.LoggedEntry, fields []zap.Field) bool {
+	for _, want := range fields {
+		found := false
+		for _, got := range entry.Context {
+			if got.Equals(want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func describeFields(fields []zap.Field) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	return fmt.Sprintf(" with fields %v", enc.Fields)
+}
@@ -0,0 +1,44 @@
+package log_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/fmotalleb/go-tools/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type recordingTB struct {
+	failures []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestObserved(t *testing.T) {
+	logger, logs := log.NewObserved(zapcore.DebugLevel)
+	ctx := log.WithLogger(context.Background(), logger)
+	log.FromContext(ctx).Named("db").With(zap.String("table", "users")).
+		Warn("slow query detected", zap.Int("rows", 42))
+	log.FromContext(ctx).Info("done")
+
+	logs.AssertLogged(t, zapcore.WarnLevel, "slow query", zap.Int("rows", 42), zap.String("table", "users"))
+	logs.AssertLogged(t, zapcore.InfoLevel, "")
+	logs.AssertNotLogged(t, zapcore.ErrorLevel, "")
+
+	rec := new(recordingTB)
+	if logs.AssertLogged(rec, zapcore.WarnLevel, "slow query", zap.Int("rows", 7)) {
+		t.Error("AssertLogged passed for a field with another value")
+	}
+	if logs.AssertNotLogged(rec, zapcore.InfoLevel, "done") {
+		t.Error("AssertNotLogged passed for a logged entry")
+	}
+	if len(rec.failures) != 2 {
+		t.Errorf("recorded %d failures, want 2: %v", len(rec.failures), rec.failures)
+	}
+}
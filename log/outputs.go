@@ -0,0 +1,117 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Output is an additional destination of a logger with its own encoding and minimum level,
+// see Builder.AddOutput.
+type Output struct {
+	writer        io.Writer
+	level         *zapcore.Level
+	encoding      string
+	encoderConfig *zapcore.EncoderConfig
+}
+
+// OutputOption configures an Output.
+type OutputOption = func(*Output)
+
+// OutputLevel sets the minimum level written to the output, entries must pass the logger level
+// (and per name levels) too (Default every entry of the logger).
+func OutputLevel(level zapcore.Level) OutputOption {
+	return func(o *Output) {
+		o.level = &level
+	}
+}
+
+// OutputEncoding sets the encoding of the output, `json` or `console` (Default the builder encoding).
+func OutputEncoding(encoding string) OutputOption {
+	return func(o *Output) {
+		o.encoding = encoding
+	}
+}
+
+// OutputJSON encodes the output as JSON.
+func OutputJSON() OutputOption {
+	return OutputEncoding("json")
+}
+
+// OutputConsole encodes the output for humans.
+func OutputConsole() OutputOption {
+	return OutputEncoding("console")
+}
+
+// OutputEncoderConfig sets the encoder config of the output (Default the builder encoder config).
+func OutputEncoderConfig(config zapcore.EncoderConfig) OutputOption {
+	return func(o *Output) {
+		o.encoderConfig = &config
+	}
+}
+
+// AddOutput adds an output writing to w, e.g. os.Stderr or writer.NewRotateWriter, next to the
+// output paths; the outputs are combined with zapcore.NewTee.
+func (b *Builder) AddOutput(w io.Writer, opts ...OutputOption) *Builder {
+	builder := *b
+	output := Output{writer: w}
+	for _, opt := range opts {
+		opt(&output)
+	}
+	builder.outputs = append(builder.outputs, output)
+	return &builder
+}
+
+func (b *Builder) outputCores() ([]zapcore.Core, error) {
+	cores := make([]zapcore.Core, 0, len(b.outputs))
+	for i, output := range b.outputs {
+		config := b.encoderConfig
+		if output.encoderConfig != nil {
+			config = *output.encoderConfig
+		}
+		encoding := b.encoding
+		if output.encoding != "" {
+			encoding = output.encoding
+		}
+		var encoder zapcore.Encoder
+		switch encoding {
+		case "json":
+			encoder = zapcore.NewJSONEncoder(config)
+		case "console":
+			encoder = zapcore.NewConsoleEncoder(config)
+		default:
+			return nil, fmt.Errorf("output %d: unknown encoding %q", i, encoding)
+		}
+		level := zapcore.DebugLevel
+		if output.level != nil {
+			level = *output.level
+		}
+		cores = append(cores, zapcore.NewCore(encoder, zapcore.AddSync(output.writer), level))
+	}
+	return cores, nil
+}
+
+// wrapCore tees the outputs with the core of the output paths, samples them and enforces the
+// logger level on top of them, the inner cores let every level through.
+func (b *Builder) wrapCore(level zap.AtomicLevel, spec *LevelSpec) (zap.Option, error) {
+	outputs, err := b.outputCores()
+	if err != nil {
+		return nil, err
+	}
+	if spec == nil {
+		spec = new(LevelSpec)
+	}
+	sampling := b.sampling
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		if len(outputs) > 0 {
+			core = zapcore.NewTee(append([]zapcore.Core{core}, outputs...)...)
+		}
+		if sampling != nil {
+			core = zapcore.NewSamplerWithOptions(core, time.Second, sampling.Initial, sampling.Thereafter)
+		}
+		return NewNameLevelCore(core, level, spec)
+	}), nil
+}
@@ -0,0 +1,67 @@
+package log_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fmotalleb/go-tools/log"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestBuilderOutputs(t *testing.T) {
+	var console, all, errs bytes.Buffer
+	logger, err := log.NewBuilder().
+		Silent().
+		Level("debug").
+		ServiceName("api").
+		AddOutput(&console, log.OutputConsole(), log.OutputLevel(zapcore.InfoLevel)).
+		AddOutput(&all, log.OutputJSON()).
+		AddOutput(&errs, log.OutputLevel(zapcore.ErrorLevel)).
+		Build()
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	logger.Debug("debug line")
+	logger.Info("info line")
+	logger.Error("error line")
+
+	if got := strings.Count(all.String(), "\n"); got != 3 {
+		t.Errorf("json output has %d lines, want 3:\n%s", got, all.String())
+	}
+	if !strings.Contains(all.String(), `"service":"api"`) {
+		t.Errorf("json output misses initial fields:\n%s", all.String())
+	}
+	if strings.Contains(console.String(), "debug line") || !strings.Contains(console.String(), "\tinfo line") {
+		t.Errorf("unexpected console output:\n%s", console.String())
+	}
+	if got := strings.Count(errs.String(), "\n"); got != 1 || !strings.Contains(errs.String(), "error line") {
+		t.Errorf("unexpected error output:\n%s", errs.String())
+	}
+
+	if _, err := log.NewBuilder().Silent().AddOutput(&all, log.OutputEncoding("xml")).Build(); err == nil {
+		t.Error("expected error for unknown encoding")
+	}
+}
+
+func TestBuilderOutputsFollowLoggerLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger, level, err := log.NewBuilder().
+		Silent().
+		Levels("warn,worker=debug").
+		AddOutput(&out).
+		BuildWithLevel()
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	logger.Info("dropped")
+	logger.Named("worker").Debug("worker debug")
+	level.SetLevel(zapcore.InfoLevel)
+	logger.Info("info after change")
+
+	if strings.Contains(out.String(), "dropped") ||
+		!strings.Contains(out.String(), "worker debug") ||
+		!strings.Contains(out.String(), "info after change") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,166 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// DefaultRingSize is the number of entries a Ring keeps when created with a size of zero.
+const DefaultRingSize = 256
+
+// Ring keeps the last entries of a logger at every level in memory, to be dumped on demand
+// or when the process panics. Field values encoded lazily, such as zap.Any or zap.Stringer ones,
+// are kept as their JSON encoding at the time of logging.
+type Ring struct {
+	mu      sync.Mutex
+	entries []observer.LoggedEntry
+	next    int
+	full    bool
+}
+
+// NewRing creates a ring keeping the last size entries (Default DefaultRingSize).
+func NewRing(size int) *Ring {
+	if size <= 0 {
+		size = DefaultRingSize
+	}
+	return &Ring{entries: make([]observer.LoggedEntry, size)}
+}
+
+// Core returns a core recording into the ring, see Builder.Ring or combine it with zapcore.NewTee.
+func (r *Ring) Core() zapcore.Core {
+	return &ringCore{ring: r}
+}
+
+func (r *Ring) add(entry observer.LoggedEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Entries returns the kept entries, oldest first.
+func (r *Ring) Entries() []observer.LoggedEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]observer.LoggedEntry(nil), r.entries[:r.next]...)
+	}
+	entries := make([]observer.LoggedEntry, 0, len(r.entries))
+	entries = append(entries, r.entries[r.next:]...)
+	return append(entries, r.entries[:r.next]...)
+}
+
+// Dump writes the kept entries to w as JSON lines, oldest first.
+func (r *Ring) Dump(w io.Writer) error {
+	enc := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	for _, entry := range r.Entries() {
+		buf, err := enc.EncodeEntry(entry.Entry, entry.Context)
+		if err != nil {
+			return fmt.Errorf("failed to encode log entry: %w", err)
+		}
+		_, err = w.Write(buf.Bytes())
+		buf.Free()
+		if err != nil {
+			return fmt.Errorf("failed to dump log entries: %w", err)
+		}
+	}
+	return nil
+}
+
+// DumpOnPanic dumps the kept entries to w if the calling goroutine panics and re-panics,
+// it must be deferred directly: `defer ring.DumpOnPanic(os.Stderr)`.
+func (r *Ring) DumpOnPanic(w io.Writer) {
+	if recovered := recover(); recovered != nil {
+		_ = r.Dump(w)
+		panic(recovered)
+	}
+}
+
+// Ring records every entry of the logger into ring, whatever the logger level.
+func (b *Builder) Ring(ring *Ring) *Builder {
+	builder := *b
+	builder.ring = ring
+	return &builder
+}
+
+type ringCore struct {
+	ring   *Ring
+	fields []zapcore.Field
+}
+
+func (c *ringCore) Enabled(zapcore.Level) bool {
+	return true
+}
+
+func (c *ringCore) With(fields []zapcore.Field) zapcore.Core {
+	return &ringCore{ring: c.ring, fields: append(c.fields[:len(c.fields):len(c.fields)], snapshotFields(fields)...)}
+}
+
+func (c *ringCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return checked.AddCore(entry, c)
+}
+
+func (c *ringCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, snapshotFields(fields)...)
+	c.ring.add(observer.LoggedEntry{Entry: entry, Context: all})
+	return nil
+}
+
+func (c *ringCore) Sync() error {
+	return nil
+}
+
+// snapshotEncoderConfig encodes fields alone, without any entry metadata.
+var snapshotEncoderConfig = func() zapcore.EncoderConfig {
+	cfg := zap.NewProductionEncoderConfig()
+	cfg.TimeKey, cfg.LevelKey, cfg.NameKey, cfg.CallerKey = "", "", "", ""
+	cfg.FunctionKey, cfg.MessageKey, cfg.StacktraceKey = "", "", ""
+	return cfg
+}()
+
+// snapshotFields replaces the fields holding references, which encoders only read when writing,
+// by their JSON encoding, so the kept entries do not change with the values they were logged with.
+func snapshotFields(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, 0, len(fields))
+	for _, field := range fields {
+		switch field.Type {
+		case zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType,
+			zapcore.BinaryType, zapcore.ByteStringType, zapcore.ReflectType, zapcore.StringerType,
+			zapcore.ErrorType:
+			out = append(out, snapshotField(field)...)
+		default:
+			out = append(out, field)
+		}
+	}
+	return out
+}
+
+func snapshotField(field zapcore.Field) []zapcore.Field {
+	buf, err := zapcore.NewJSONEncoder(snapshotEncoderConfig).EncodeEntry(zapcore.Entry{}, []zapcore.Field{field})
+	if err != nil {
+		return []zapcore.Field{zap.String(field.Key+"Error", err.Error())}
+	}
+	defer buf.Free()
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &values); err != nil {
+		return []zapcore.Field{zap.String(field.Key+"Error", err.Error())}
+	}
+	out := make([]zapcore.Field, 0, len(values))
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		out = append(out, zap.Reflect(key, values[key]))
+	}
+	return out
+}
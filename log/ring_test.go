@@ -0,0 +1,69 @@
+package log_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fmotalleb/go-tools/log"
+	"go.uber.org/zap"
+)
+
+func TestRing(t *testing.T) {
+	ring := log.NewRing(3)
+	logger := log.NewBuilder().Silent().Level("error").ServiceName("api").Ring(ring).MustBuild()
+	for i := range 5 {
+		logger.Debug("tick", zap.Int("i", i))
+	}
+
+	entries := ring.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("ring kept %d entries, want 3", len(entries))
+	}
+	for n, entry := range entries {
+		if got := entry.ContextMap()["i"]; got != int64(n+2) {
+			t.Errorf("entry %d has i=%v, want %d", n, got, n+2)
+		}
+		if got := entry.ContextMap()["service"]; got != "api" {
+			t.Errorf("entry %d has service=%v, want api", n, got)
+		}
+	}
+
+	var dump bytes.Buffer
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("DumpOnPanic swallowed the panic")
+			}
+		}()
+		defer ring.DumpOnPanic(&dump)
+		panic("boom")
+	}()
+	if got := strings.Count(dump.String(), "\n"); got != 3 || !strings.Contains(dump.String(), `"i":4`) {
+		t.Errorf("unexpected dump:\n%s", dump.String())
+	}
+}
+
+func TestRingSnapshotsFields(t *testing.T) {
+	ring := log.NewRing(0)
+	logger := log.NewBuilder().Silent().Ring(ring).MustBuild()
+	labels := map[string]string{"env": "dev"}
+	logger.Info("loaded", zap.Any("labels", labels), zap.Strings("tags", []string{"a"}))
+	labels["env"] = "prod"
+
+	entries := ring.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("ring kept %d entries, want 1", len(entries))
+	}
+	if got := fmt.Sprintf("%s", entries[0].ContextMap()["labels"]); got != `{"env":"dev"}` {
+		t.Errorf("labels = %s, want the logged value", got)
+	}
+	var dump bytes.Buffer
+	if err := ring.Dump(&dump); err != nil {
+		t.Fatalf("dump failed: %v", err)
+	}
+	if !strings.Contains(dump.String(), `"labels":{"env":"dev"},"tags":["a"]`) {
+		t.Errorf("unexpected dump:\n%s", dump.String())
+	}
+}
@@ -2,6 +2,7 @@ package log
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -44,6 +45,10 @@ type Builder struct {
 	initialFields     map[string]interface{}
 	hooks             []func(zapcore.Entry) error
 	name              string
+	atomicLevel       *zap.AtomicLevel
+	levels            string
+	outputs           []Output
+	ring              *Ring
 }
 
 // NewBuilder creates a new LoggerBuilder with default values.
@@ -391,10 +396,48 @@ func (b *Builder) Name(name string) *Builder {
 	return &builder
 }
 
+// AtomicLevel makes the logger use level, set to the configured level on build, so it can be
+// shared by several loggers and changed at runtime, see [LevelController].
+func (b *Builder) AtomicLevel(level zap.AtomicLevel) *Builder {
+	builder := *b
+	builder.atomicLevel = &level
+	return &builder
+}
+
+// Levels sets per logger name levels from a spec like `info,config-reader=debug,broadcast=warn`,
+// a bare level in the spec replaces the configured level, see [ParseLevelSpec].
+func (b *Builder) Levels(spec string) *Builder {
+	builder := *b
+	builder.levels = spec
+	return &builder
+}
+
 // Build creates the zap logger with the configured options.
 func (b *Builder) Build() (*zap.Logger, error) {
+	logger, _, err := b.BuildWithLevel()
+	return logger, err
+}
+
+// BuildWithLevel creates the zap logger and returns the handle of its level, which changes the
+// level of the running logger, see [LevelController].
+func (b *Builder) BuildWithLevel() (*zap.Logger, zap.AtomicLevel, error) {
+	level := zap.NewAtomicLevelAt(b.level)
+	if b.atomicLevel != nil {
+		level = *b.atomicLevel
+		level.SetLevel(b.level)
+	}
+	var spec *LevelSpec
+	if b.levels != "" {
+		var err error
+		if spec, err = ParseLevelSpec(b.levels); err != nil {
+			return nil, level, fmt.Errorf("failed to build logger: %w", err)
+		}
+		if spec.HasDefault {
+			level.SetLevel(spec.Default)
+		}
+	}
 	config := zap.Config{
-		Level:             zap.NewAtomicLevelAt(b.level),
+		Level:             level,
 		Development:       b.development,
 		DisableCaller:     b.disableCaller,
 		DisableStacktrace: b.disableStacktrace,
@@ -403,12 +446,25 @@ func (b *Builder) Build() (*zap.Logger, error) {
 		EncoderConfig:     b.encoderConfig,
 		OutputPaths:       b.outputPaths,
 		ErrorOutputPaths:  b.errorOutputPaths,
-		InitialFields:     b.initialFields,
+	}
+	wrapped := spec != nil || len(b.outputs) > 0
+	if wrapped {
+		// The wrapping core filters and samples entries, the inner core lets every level through.
+		config.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+		config.Sampling = nil
 	}
 
 	logger, err := config.Build()
 	if err != nil {
-		return nil, fmt.Errorf("failed to build logger: %w", err)
+		return nil, level, fmt.Errorf("failed to build logger: %w", err)
+	}
+
+	if wrapped {
+		wrap, err := b.wrapCore(level, spec)
+		if err != nil {
+			return nil, level, fmt.Errorf("failed to build logger: %w", err)
+		}
+		logger = logger.WithOptions(wrap)
 	}
 
 	// Add hooks if any
@@ -416,12 +472,38 @@ func (b *Builder) Build() (*zap.Logger, error) {
 		logger = logger.WithOptions(zap.Hooks(b.hooks...))
 	}
 
+	// The ring records every level, outside of the level filter and hooks
+	if ring := b.ring; ring != nil {
+		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, ring.Core())
+		}))
+	}
+
+	// Initial fields are added once every core is in place
+	if len(b.initialFields) > 0 {
+		logger = logger.With(b.initialFieldList()...)
+	}
+
 	// Set name if provided
 	if b.name != "" {
 		logger = logger.Named(b.name)
 	}
 
-	return logger, nil
+	return logger, level, nil
+}
+
+// initialFieldList returns the initial fields sorted by key, as zap.Config would add them.
+func (b *Builder) initialFieldList() []zap.Field {
+	keys := make([]string, 0, len(b.initialFields))
+	for key := range b.initialFields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	fields := make([]zap.Field, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, zap.Any(key, b.initialFields[key]))
+	}
+	return fields
 }
 
 // MustBuild creates the logger and panics on error.
@@ -440,6 +522,9 @@ func (b *Builder) FromEnv() *Builder {
 	// Core configuration
 	level := env.Or("ZAPLOG_LEVEL", DefaultLevel.String())
 	builder = *builder.Level(level)
+	if levels := env.Or("ZAPLOG_LEVELS"); levels != "" {
+		builder = *builder.Levels(levels)
+	}
 
 	// Development mode
 	isDev := env.BoolOr("ZAPLOG_DEVELOPMENT", DefaultDevelopment)
@@ -1,10 +1,10 @@
 package matcher
 
 import (
-	"errors"
 	"reflect"
-	"strings"
+	"sync"
 
+	"github.com/fmotalleb/go-tools/decoder/variant"
 	"github.com/fmotalleb/go-tools/matcher/glob"
 	"github.com/fmotalleb/go-tools/matcher/regexp"
 	"github.com/fmotalleb/go-tools/matcher/wildcard"
@@ -19,52 +19,43 @@ type matcher interface {
 	MarshalText() ([]byte, error)
 }
 
-func (m *Matcher) Decode(from reflect.Type, val interface{}) (any, error) {
-	var ty, pat string
-	if val, ok := val.(string); ok {
-		ty, pat = fromStr(val)
-	}
-	switch ty {
-	case "wildcard", "domain", "wc":
-		var err error
-		var mat matcher
-		if mat, err = wildcard.Compile(pat); err != nil {
-			return nil, err
-		}
-		m.matcher = mat
-		return m, nil
-	case "glob", "file", "files":
-		var err error
-		var mat matcher
-		if mat, err = glob.Compile(pat); err != nil {
-			return nil, err
-		}
-		m.matcher = mat
-		return m, nil
-	case "regex", "regxp", "grep":
-		var err error
-		var mat matcher
-		if mat, err = regexp.Compile(pat); err != nil {
+// variants holds the matcher kinds, a string without a `kind:` prefix is a wildcard pattern.
+var variants = sync.OnceValue(func() *variant.Registry[matcher] {
+	r := variant.NewRegistry[matcher](variant.WithDefault("wildcard"))
+	variant.Register(r, "wildcard", compiler(wildcard.Compile),
+		variant.WithAliases("domain", "wc"), variant.WithArgs("pattern"))
+	variant.Register(r, "glob", compiler(glob.Compile),
+		variant.WithAliases("file", "files"), variant.WithArgs("pattern"))
+	variant.Register(r, "regex", compiler(regexp.Compile),
+		variant.WithAliases("regxp", "grep"), variant.WithArgs("pattern"))
+	return r
+})
+
+func compiler[M matcher](compile func(string) (M, error)) func(string) (matcher, error) {
+	return func(pattern string) (matcher, error) {
+		m, err := compile(pattern)
+		if err != nil {
 			return nil, err
 		}
-		m.matcher = mat
 		return m, nil
 	}
+}
 
-	return nil, errors.New("failed to find matcher variant")
+// Decode builds the matcher from `kind:pattern` (e.g. `glob:*.go`), a bare wildcard pattern,
+// `{type: kind, pattern: ...}` or `[kind, pattern]`.
+func (m *Matcher) Decode(_ reflect.Type, val interface{}) (any, error) {
+	mat, err := variants().Decode(val, nil)
+	if err != nil {
+		return nil, err
+	}
+	m.matcher = mat
+	return m, nil
 }
 
-func fromStr(val interface{}) (string, string) {
-	var ty, pat string
-	str := val.(string)
-	split := strings.Split(str, ":")
-	switch len(split) {
-	case 1:
-		ty = "wildcard"
-		pat = str
-	default:
-		ty = split[0]
-		pat = strings.Join(split[1:], ":")
+// MarshalText encodes the matcher as `kind:pattern`, an empty Matcher encodes as empty text.
+func (m Matcher) MarshalText() ([]byte, error) {
+	if m.matcher == nil {
+		return []byte{}, nil
 	}
-	return ty, pat
+	return m.matcher.MarshalText()
 }
@@ -1,11 +1,16 @@
 package template
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
 
 	"github.com/go-viper/mapstructure/v2"
 )
 
+// StringTemplateEvaluate returns a hook that evaluates every string input as a template against data.
+// The template's dot is the data slice itself, e.g. `{{ (index . 0).Host }}`.
+// Any literal `{{` in the input is parsed as well, prefer `template:"true"` fields and [EvaluateOnStruct].
 func StringTemplateEvaluate(data ...any) mapstructure.DecodeHookFunc {
 	return func(from, to reflect.Type, val interface{}) (interface{}, error) {
 		if from.Kind() != reflect.String {
@@ -16,56 +21,119 @@ func StringTemplateEvaluate(data ...any) mapstructure.DecodeHookFunc {
 	}
 }
 
-// func EvaluateOnStruct(v any, data any) {
-// 	visited := make(map[uintptr]bool)
-// 	findTemplateFieldsRecursive(data, reflect.ValueOf(v), visited, "")
-// }
-
-// func findTemplateFieldsRecursive(data any, val reflect.Value, visited map[uintptr]bool, path string) {
-// 	if !val.IsValid() {
-// 		return
-// 	}
-
-// 	// Dereference pointers
-// 	for val.Kind() == reflect.Pointer {
-// 		if val.IsNil() {
-// 			return
-// 		}
-// 		ptr := val.Pointer()
-// 		if visited[ptr] {
-// 			return
-// 		}
-// 		visited[ptr] = true
-// 		val = val.Elem()
-// 	}
+// EvaluateOnStruct evaluates the fields tagged `template:"true"` of the struct v points to against data,
+// walking nested structs, pointers, slices and maps.
+//
+// Tagged fields must hold strings: a string, a pointer to one, or a slice, array or map of them.
+// Errors of every field are joined and carry the field path, e.g. `.Routes[0].URL`.
+func EvaluateOnStruct(v any, data any) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Pointer || val.IsNil() {
+		return errors.New("failed to evaluate templates: expected a non-nil pointer")
+	}
+	w := &structWalker{data: data, visited: make(map[uintptr]bool)}
+	w.walk(val, "")
+	return errors.Join(w.errs...)
+}
 
-// 	t := val.Type()
+type structWalker struct {
+	data    any
+	visited map[uintptr]bool
+	errs    []error
+}
 
-// 	switch val.Kind() {
-// 	case reflect.Struct:
-// 		for i := 0; i < val.NumField(); i++ {
-// 			f := t.Field(i)
-// 			fv := val.Field(i)
+func (w *structWalker) walk(val reflect.Value, path string) {
+	for val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+		if val.Kind() == reflect.Pointer {
+			ptr := val.Pointer()
+			if w.visited[ptr] {
+				return
+			}
+			w.visited[ptr] = true
+		}
+		val = val.Elem()
+	}
 
-// 			currentPath := path + "." + f.Name
-// 			if f.Tag.Get("template") == "true" {
-// 				field := val.Field(i)
-// 				input := field.text
-// 				result := EvaluateTemplate(input, data)
-// 				field.text = result
-// 			}
+	switch val.Kind() {
+	case reflect.Struct:
+		t := val.Type()
+		for i := range val.NumField() {
+			f := t.Field(i)
+			fv := val.Field(i)
+			if !fv.CanSet() {
+				continue
+			}
+			currentPath := path + "." + f.Name
+			if f.Tag.Get("template") == "true" {
+				w.evaluateField(fv, currentPath)
+				continue
+			}
+			w.walk(fv, currentPath)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range val.Len() {
+			w.walk(val.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.Map:
+		if !mayHoldFields(val.Type().Elem()) {
+			return
+		}
+		iter := val.MapRange()
+		for iter.Next() {
+			// Map values are not addressable, walk a copy and store it back.
+			item := reflect.New(iter.Value().Type()).Elem()
+			item.Set(iter.Value())
+			w.walk(item, fmt.Sprintf("%s[%v]", path, iter.Key()))
+			val.SetMapIndex(iter.Key(), item)
+		}
+	default:
+	}
+}
 
-// 			findTemplateFieldsRecursive(data, fv, visited, currentPath)
-// 		}
+// mayHoldFields reports whether values of t may contain struct fields.
+func mayHoldFields(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	default:
+		return false
+	}
+}
 
-// 	case reflect.Slice, reflect.Array:
-// 		for i := 0; i < val.Len(); i++ {
-// 			findTemplateFieldsRecursive(data, val.Index(i), visited, fmt.Sprintf("%s[%d]", path, i))
-// 		}
+// evaluateField renders the tagged field fv in place.
+func (w *structWalker) evaluateField(fv reflect.Value, path string) {
+	switch {
+	case fv.Kind() == reflect.String:
+		w.evaluate(fv, path)
+	case fv.Kind() == reflect.Pointer && fv.Type().Elem().Kind() == reflect.String:
+		if !fv.IsNil() {
+			w.evaluate(fv.Elem(), path)
+		}
+	case (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) && fv.Type().Elem().Kind() == reflect.String:
+		for i := range fv.Len() {
+			w.evaluate(fv.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case fv.Kind() == reflect.Map && fv.Type().Elem().Kind() == reflect.String:
+		iter := fv.MapRange()
+		for iter.Next() {
+			item := reflect.New(iter.Value().Type()).Elem()
+			item.Set(iter.Value())
+			w.evaluate(item, fmt.Sprintf("%s[%v]", path, iter.Key()))
+			fv.SetMapIndex(iter.Key(), item)
+		}
+	default:
+		w.errs = append(w.errs, fmt.Errorf("template field %s must hold strings, got %s", path, fv.Type()))
+	}
+}
 
-// 	case reflect.Map:
-// 		for _, key := range val.MapKeys() {
-// 			findTemplateFieldsRecursive(data, val.MapIndex(key), visited, fmt.Sprintf("%s[%v]", path, key))
-// 		}
-// 	}
-// }
+func (w *structWalker) evaluate(fv reflect.Value, path string) {
+	result, err := EvaluateTemplate(fv.String(), w.data)
+	if err != nil {
+		w.errs = append(w.errs, fmt.Errorf("failed to evaluate template field %s: %w", path, err))
+		return
+	}
+	fv.SetString(result)
+}
@@ -0,0 +1,227 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"sync"
+	"text/template"
+)
+
+// DefaultCacheSize is the number of parsed templates an [Engine] keeps by default.
+const DefaultCacheSize = 512
+
+// Engine parses and executes templates with a fixed configuration, keeping recently used
+// templates parsed in a bounded LRU cache. It is safe for concurrent use.
+type Engine struct {
+	leftDelim  string
+	rightDelim string
+	missingKey string
+	funcs      template.FuncMap
+	cacheSize  int
+	loaders    []func(*template.Template) error
+	sandbox    *Sandbox
+
+	base  *template.Template
+	cache *lru[*template.Template]
+}
+
+// EngineOption configures an [Engine].
+type EngineOption = func(*Engine)
+
+// WithDelims sets the action delimiters (Default `{{` and `}}`).
+func WithDelims(left, right string) EngineOption {
+	return func(e *Engine) {
+		e.leftDelim, e.rightDelim = left, right
+	}
+}
+
+// WithMissingKey sets what a missing map key renders to: `error`, `zero` or `default` (Default `error`),
+// see text/template's `missingkey` option.
+func WithMissingKey(mode string) EngineOption {
+	return func(e *Engine) {
+		e.missingKey = mode
+	}
+}
+
+// WithCacheSize sets the number of parsed templates kept (Default [DefaultCacheSize]); 0 disables the cache.
+func WithCacheSize(size int) EngineOption {
+	return func(e *Engine) {
+		e.cacheSize = size
+	}
+}
+
+// WithFuncs adds funcs to (or overrides) the default funcs.
+func WithFuncs(funcs template.FuncMap) EngineOption {
+	return func(e *Engine) {
+		maps.Copy(e.funcs, funcs)
+	}
+}
+
+// WithPartials parses the files of fsys matching patterns as named templates, callable from any
+// template as `{{ template "header.tmpl" . }}`. Names are the file base names.
+func WithPartials(fsys fs.FS, patterns ...string) EngineOption {
+	return func(e *Engine) {
+		e.loaders = append(e.loaders, func(t *template.Template) error {
+			_, err := t.ParseFS(fsys, patterns...)
+			return err
+		})
+	}
+}
+
+// WithPartialsDir parses the files of dir matching patterns as named templates, see [WithPartials].
+func WithPartialsDir(dir string, patterns ...string) EngineOption {
+	return WithPartials(os.DirFS(dir), patterns...)
+}
+
+// WithNamedTemplate adds text as a template callable as `{{ template "name" . }}`.
+func WithNamedTemplate(name, text string) EngineOption {
+	return func(e *Engine) {
+		e.loaders = append(e.loaders, func(t *template.Template) error {
+			_, err := t.New(name).Parse(text)
+			return err
+		})
+	}
+}
+
+// NewEngine creates an [Engine] with the default funcs, altered by opts.
+// It fails when partials cannot be loaded or parsed.
+func NewEngine(opts ...EngineOption) (*Engine, error) {
+	e := &Engine{
+		missingKey: "error",
+		funcs:      maps.Clone(fMap()),
+		cacheSize:  DefaultCacheSize,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.sandbox != nil {
+		maps.Copy(e.funcs, e.sandbox.funcs())
+	}
+	e.base = template.New("").
+		Delims(e.leftDelim, e.rightDelim).
+		Option("missingkey=" + e.missingKey).
+		Funcs(e.funcs)
+	for _, load := range e.loaders {
+		if err := load(e.base); err != nil {
+			return nil, errors.Join(errors.New("failed to load partials"), err)
+		}
+	}
+	if e.cacheSize > 0 {
+		e.cache = newLRU[*template.Template](e.cacheSize)
+	}
+	return e, nil
+}
+
+var defaultEngine = sync.OnceValue(func() *Engine {
+	e, err := NewEngine()
+	if err != nil {
+		panic(err)
+	}
+	return e
+})
+
+// Default returns the shared [Engine] used by the package-level functions.
+func Default() *Engine {
+	return defaultEngine()
+}
+
+// lenientEngine backs [EvaluateTemplateWithFuncs], which has always rendered missing keys as `<no value>`.
+var lenientEngine = sync.OnceValue(func() *Engine {
+	e, err := NewEngine(WithMissingKey("default"), WithCacheSize(0))
+	if err != nil {
+		panic(err)
+	}
+	return e
+})
+
+// Parse parses text, or returns it from the cache.
+func (e *Engine) Parse(text string) (*template.Template, error) {
+	if e.cache != nil {
+		if tmpl, ok := e.cache.get(text); ok {
+			return tmpl, nil
+		}
+	}
+	tmpl, err := e.parse(text, nil)
+	if err != nil {
+		return nil, err
+	}
+	if e.cache != nil {
+		e.cache.add(text, tmpl)
+	}
+	return tmpl, nil
+}
+
+// Evaluate parses (or takes from the cache) and executes text against data.
+func (e *Engine) Evaluate(text string, data any) (string, error) {
+	output := new(bytes.Buffer)
+	if err := e.Execute(output, text, data); err != nil {
+		return "", err
+	}
+	return output.String(), nil
+}
+
+// EvaluateWithFuncs executes text against data with funcs added to (or overriding) the engine's funcs.
+// The template is parsed on every call since the funcs take part in parsing.
+func (e *Engine) EvaluateWithFuncs(text string, data any, funcs template.FuncMap) (string, error) {
+	tmpl, err := e.parse(text, funcs)
+	if err != nil {
+		return "", err
+	}
+	merged := maps.Clone(e.funcs)
+	maps.Copy(merged, funcs)
+	output := new(bytes.Buffer)
+	if err := e.sandbox.execute(output, tmpl, merged, data); err != nil {
+		return "", err
+	}
+	return output.String(), nil
+}
+
+// Execute parses (or takes from the cache) text and writes its output against data to w.
+func (e *Engine) Execute(w io.Writer, text string, data any) error {
+	tmpl, err := e.Parse(text)
+	if err != nil {
+		return err
+	}
+	return e.sandbox.execute(w, tmpl, e.funcs, data)
+}
+
+// ExecuteTemplate writes the output of the named partial against data to w.
+func (e *Engine) ExecuteTemplate(w io.Writer, name string, data any) error {
+	if e.base.Lookup(name) == nil {
+		return fmt.Errorf("template %q is not defined", name)
+	}
+	return e.sandbox.run(w, e.base, e.funcs, func(t *template.Template, out io.Writer) error {
+		return t.ExecuteTemplate(out, name, data)
+	})
+}
+
+// CacheLen returns the number of parsed templates currently cached.
+func (e *Engine) CacheLen() int {
+	if e.cache == nil {
+		return 0
+	}
+	return e.cache.len()
+}
+
+func (e *Engine) parse(text string, funcs template.FuncMap) (*template.Template, error) {
+	base, err := e.base.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	if funcs != nil {
+		base.Funcs(funcs)
+		if e.sandbox != nil {
+			base.Funcs(e.sandbox.funcs())
+		}
+	}
+	tmpl, err := base.New("template").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,72 @@
+package template_test
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"github.com/alecthomas/assert/v2"
+	tmpl "github.com/fmotalleb/go-tools/template"
+)
+
+func TestEngine_Cache(t *testing.T) {
+	e, err := tmpl.NewEngine(tmpl.WithCacheSize(2))
+	assert.NoError(t, err)
+	for i := range 3 {
+		out, err := e.Evaluate("{{ .N }}-"+strconv.Itoa(i), map[string]int{"N": i})
+		assert.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(i)+"-"+strconv.Itoa(i), out)
+	}
+	assert.Equal(t, 2, e.CacheLen())
+
+	first, err := e.Parse("{{ .N }}-2")
+	assert.NoError(t, err)
+	second, err := e.Parse("{{ .N }}-2")
+	assert.NoError(t, err)
+	assert.True(t, first == second)
+
+	_, err = e.Parse("{{ .N ")
+	assert.Error(t, err)
+	assert.Equal(t, 2, e.CacheLen())
+}
+
+func TestEngine_DelimsAndMissingKey(t *testing.T) {
+	e, err := tmpl.NewEngine(tmpl.WithDelims("[[", "]]"), tmpl.WithMissingKey("zero"))
+	assert.NoError(t, err)
+	out, err := e.Evaluate("{{ raw }} [[ .A ]][[ .B ]]", map[string]string{"A": "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, "{{ raw }} a", out)
+
+	_, err = tmpl.Default().Evaluate("{{ .B }}", map[string]string{})
+	assert.Error(t, err)
+
+	out, err = tmpl.EvaluateTemplateWithFuncs("{{ .B }}", map[string]any{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "<no value>", out)
+}
+
+func TestEngine_Partials(t *testing.T) {
+	fsys := fstest.MapFS{
+		"partials/header.tmpl": {Data: []byte(`# {{ .Title }}`)},
+		"partials/skip.txt":    {Data: []byte(`ignored`)},
+	}
+	e, err := tmpl.NewEngine(
+		tmpl.WithPartials(fsys, "partials/*.tmpl"),
+		tmpl.WithNamedTemplate("footer", `-- {{ .Author }}`),
+	)
+	assert.NoError(t, err)
+
+	data := map[string]string{"Title": "Hello", "Author": "me"}
+	out, err := e.Evaluate(`{{ template "header.tmpl" . }} / {{ template "footer" . }}`, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "# Hello / -- me", out)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, e.ExecuteTemplate(buf, "footer", data))
+	assert.Equal(t, "-- me", buf.String())
+	assert.Error(t, e.ExecuteTemplate(buf, "skip.txt", data))
+
+	_, err = tmpl.NewEngine(tmpl.WithPartials(fsys, "missing/*.tmpl"))
+	assert.Error(t, err)
+}
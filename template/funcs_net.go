@@ -0,0 +1,246 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/fmotalleb/go-tools/decoder/types"
+)
+
+// netFuncs take their subject last, so they chain in pipelines: `{{ .Addr | portOf }}`, `{{ .IP | ipAdd 1 }}`.
+func netFuncs() template.FuncMap {
+	return template.FuncMap{
+		"splitHostPort": splitHostPort,
+		"joinHostPort":  joinHostPort,
+		"hostOf":        hostOf,
+		"portOf":        portOf,
+		"cidrContains":  cidrContains,
+		"cidrHost":      cidrHost,
+		"cidrSubnet":    cidrSubnet,
+		"cidrNetmask":   cidrNetmask,
+		"ipAdd":         ipAdd,
+		"parseURL":      parseURL,
+		"urlWith":       urlWith,
+	}
+}
+
+// splitHostPort splits `host:port` into a [host, port] list, see types.ParseHostPort.
+func splitHostPort(addr string) ([]string, error) {
+	hp, err := types.ParseHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	return []string{hp.Host, strconv.Itoa(int(hp.Port))}, nil
+}
+
+func joinHostPort(host string, port any) (string, error) {
+	p, err := toInt(port)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(p)), nil
+}
+
+func hostOf(addr string) (string, error) {
+	hp, err := types.ParseHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	return hp.Host, nil
+}
+
+func portOf(addr string) (int, error) {
+	hp, err := types.ParseHostPort(addr)
+	if err != nil {
+		return 0, err
+	}
+	return int(hp.Port), nil
+}
+
+func cidrContains(cidr string, ip string) (bool, error) {
+	prefix, err := types.ParsePrefix(cidr)
+	if err != nil {
+		return false, err
+	}
+	addr, err := types.ParseAddr(ip)
+	if err != nil {
+		return false, err
+	}
+	return prefix.Contains(addr), nil
+}
+
+// cidrHost returns the address number hostnum of cidr, negative numbers count back from its last address.
+func cidrHost(hostnum any, cidr string) (string, error) {
+	n, err := toInt(hostnum)
+	if err != nil {
+		return "", err
+	}
+	prefix, err := types.ParsePrefix(cidr)
+	if err != nil {
+		return "", err
+	}
+	prefix = prefix.Masked()
+	offset := big.NewInt(int64(n))
+	if n < 0 {
+		size := new(big.Int).Lsh(big.NewInt(1), uint(prefix.Addr().BitLen()-prefix.Bits()))
+		offset.Add(offset, size)
+	}
+	addr, err := addToAddr(prefix.Addr(), offset)
+	if err != nil || !prefix.Contains(addr) {
+		return "", fmt.Errorf("host number %d is out of range of %s", n, prefix)
+	}
+	return addr.String(), nil
+}
+
+// cidrSubnet returns the subnet number netnum of cidr, extended by newbits bits.
+func cidrSubnet(newbits any, netnum any, cidr string) (string, error) {
+	bits, err := toInt(newbits)
+	if err != nil {
+		return "", err
+	}
+	num, err := toInt(netnum)
+	if err != nil {
+		return "", err
+	}
+	prefix, err := types.ParsePrefix(cidr)
+	if err != nil {
+		return "", err
+	}
+	prefix = prefix.Masked()
+	length := prefix.Bits() + bits
+	if bits < 0 || length > prefix.Addr().BitLen() {
+		return "", fmt.Errorf("cannot extend %s by %d bits", prefix, bits)
+	}
+	if num < 0 || big.NewInt(int64(num)).BitLen() > bits {
+		return "", fmt.Errorf("subnet number %d does not fit in %d bits", num, bits)
+	}
+	offset := new(big.Int).Lsh(big.NewInt(int64(num)), uint(prefix.Addr().BitLen()-length))
+	addr, err := addToAddr(prefix.Addr(), offset)
+	if err != nil {
+		return "", err
+	}
+	return netip.PrefixFrom(addr, length).String(), nil
+}
+
+// cidrNetmask returns the dotted netmask of an IPv4 cidr, e.g. `255.255.255.0`.
+func cidrNetmask(cidr string) (string, error) {
+	prefix, err := types.ParsePrefix(cidr)
+	if err != nil {
+		return "", err
+	}
+	if !prefix.Addr().Is4() {
+		return "", fmt.Errorf("netmask of %s: not an IPv4 prefix", prefix)
+	}
+	return net.IP(net.CIDRMask(prefix.Bits(), prefix.Addr().BitLen())).String(), nil
+}
+
+// ipAdd adds n, which may be negative, to the address ip.
+func ipAdd(n any, ip string) (string, error) {
+	delta, err := toInt(n)
+	if err != nil {
+		return "", err
+	}
+	addr, err := types.ParseAddr(ip)
+	if err != nil {
+		return "", err
+	}
+	result, err := addToAddr(addr, big.NewInt(int64(delta)))
+	if err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+func addToAddr(addr netip.Addr, offset *big.Int) (netip.Addr, error) {
+	value := new(big.Int).SetBytes(addr.AsSlice())
+	value.Add(value, offset)
+	size := addr.BitLen() / 8
+	if value.Sign() < 0 || value.BitLen() > addr.BitLen() {
+		return netip.Addr{}, fmt.Errorf("address %s%+d overflows", addr, offset)
+	}
+	result, _ := netip.AddrFromSlice(value.FillBytes(make([]byte, size)))
+	return result.WithZone(addr.Zone()), nil
+}
+
+// parseURL parses s, the result exposes the fields of url.URL such as `.Host` or `.Query`.
+func parseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	return u, nil
+}
+
+// urlWith returns u with one part replaced: `scheme`, `host`, `hostname`, `port`, `path`, `fragment`,
+// `user` (`name` or `name:password`) or `query.<key>`; an empty value removes a query key.
+func urlWith(part, value string, u any) (string, error) {
+	var parsed *url.URL
+	switch v := u.(type) {
+	case *url.URL:
+		copied := *v
+		parsed = &copied
+	case url.URL:
+		parsed = &v
+	case string:
+		var err error
+		if parsed, err = parseURL(v); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("urlWith: unsupported url type %T", u)
+	}
+	switch part {
+	case "scheme":
+		parsed.Scheme = value
+	case "host":
+		parsed.Host = value
+	case "hostname":
+		parsed.Host = joinHost(value, parsed.Port())
+	case "port":
+		parsed.Host = joinHost(parsed.Hostname(), value)
+	case "path":
+		parsed.Path, parsed.RawPath = value, ""
+	case "fragment":
+		parsed.Fragment, parsed.RawFragment = value, ""
+	case "user":
+		name, password, hasPassword := strings.Cut(value, ":")
+		switch {
+		case value == "":
+			parsed.User = nil
+		case hasPassword:
+			parsed.User = url.UserPassword(name, password)
+		default:
+			parsed.User = url.User(name)
+		}
+	default:
+		key, ok := strings.CutPrefix(part, "query.")
+		if !ok {
+			return "", errors.New("urlWith: unknown part " + strconv.Quote(part))
+		}
+		query := parsed.Query()
+		if value == "" {
+			query.Del(key)
+		} else {
+			query.Set(key, value)
+		}
+		parsed.RawQuery = query.Encode()
+	}
+	return parsed.String(), nil
+}
+
+func joinHost(host, port string) string {
+	if port == "" {
+		if strings.Contains(host, ":") {
+			return "[" + host + "]"
+		}
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
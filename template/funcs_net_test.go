@@ -0,0 +1,60 @@
+package template_test
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	tmpl "github.com/fmotalleb/go-tools/template"
+)
+
+func TestFuncs_Net(t *testing.T) {
+	cases := map[string]string{
+		`{{ "example.com:8080" | portOf }}`:                         "8080",
+		`{{ "[::1]:443" | hostOf }}`:                                "::1",
+		`{{ index (splitHostPort "10.0.0.1:80") 0 }}`:               "10.0.0.1",
+		`{{ joinHostPort "::1" 80 }}`:                               "[::1]:80",
+		`{{ cidrContains "10.0.0.0/8" "10.1.2.3" }}`:                "true",
+		`{{ cidrContains "10.0.0.0/8" "192.168.1.1" }}`:             "false",
+		`{{ cidrHost 5 "10.0.0.0/24" }}`:                            "10.0.0.5",
+		`{{ cidrHost -2 "10.0.0.0/24" }}`:                           "10.0.0.254",
+		`{{ cidrSubnet 8 2 "10.0.0.0/16" }}`:                        "10.0.2.0/24",
+		`{{ cidrSubnet 16 1 "fd00::/48" }}`:                         "fd00:0:0:1::/64",
+		`{{ cidrNetmask "10.0.0.0/20" }}`:                           "255.255.240.0",
+		`{{ "10.0.0.255" | ipAdd 1 }}`:                              "10.0.1.0",
+		`{{ "::1" | ipAdd -1 }}`:                                    "::",
+		`{{ (parseURL "https://a.io:8443/x?q=1").Port }}`:           "8443",
+		`{{ "https://a.io/x?q=1" | urlWith "path" "/y" }}`:          "https://a.io/y?q=1",
+		`{{ "https://a.io/x?q=1" | urlWith "query.q" "" }}`:         "https://a.io/x",
+		`{{ "https://a.io:8443/" | urlWith "hostname" "b.io" }}`:    "https://b.io:8443/",
+		`{{ parseURL "http://a.io" | urlWith "user" "u:p" }}`:       "http://u:p@a.io",
+		`{{ matches "*.example.com" "api.example.com" }}`:           "true",
+		`{{ matches "regex:^a+$" "aab" }}`:                          "false",
+		`{{ query ".services[name=api].url" . }}`:                   "http://api",
+		`{{ query ".services[*].name" . | join "," }}`:              "api,web",
+		`{{ query ".services[-1].ports[0]" . }}`:                    "80",
+		`{{ query ".services[name=none].url" . | default "none" }}`: "none",
+	}
+	data := map[string]any{
+		"services": []any{
+			map[string]any{"name": "api", "url": "http://api"},
+			map[string]any{"name": "web", "ports": []int{80, 443}},
+		},
+	}
+	for text, want := range cases {
+		out, err := tmpl.EvaluateTemplate(text, data)
+		assert.NoError(t, err, text)
+		assert.Equal(t, want, out, text)
+	}
+
+	for _, text := range []string{
+		`{{ cidrHost 256 "10.0.0.0/24" }}`,
+		`{{ cidrSubnet 8 256 "10.0.0.0/16" }}`,
+		`{{ "255.255.255.255" | ipAdd 1 }}`,
+		`{{ portOf "example.com" }}`,
+		`{{ "https://a.io" | urlWith "nope" "x" }}`,
+		`{{ query ".services[0" . }}`,
+	} {
+		_, err := tmpl.EvaluateTemplate(text, data)
+		assert.Error(t, err, text)
+	}
+}
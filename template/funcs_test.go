@@ -0,0 +1,60 @@
+package template_test
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	tmpl "github.com/fmotalleb/go-tools/template"
+)
+
+func TestFuncs_DecodeArbitraryValues(t *testing.T) {
+	cases := map[string]string{
+		`{{ (fromJSON "[1, 2]") | len }}`:            "2",
+		`{{ fromJSON "\"text\"" }}`:                  "text",
+		`{{ (fromJSON "{\"a\": 1}").a }}`:            "1",
+		`{{ index (fromYAML "- a\n- b") 1 }}`:        "b",
+		`{{ fromYAML "42" }}`:                        "42",
+		`{{ (fromTOML "a = 1").a }}`:                 "1",
+		`{{ index (fromTOML "[1, 2]") 0 }}`:          "1",
+		`{{ toJSON (list 1 "a") }}`:                  `[1,"a"]`,
+		`{{ toInt "12" | upTo 10 }}`:                 "10",
+		`{{ parseDuration "1s" }}`:                   "1000000000",
+		`{{ tryFromJSON "{" }}`:                      "<no value>",
+		`{{ tryToInt "x" }}`:                         "0",
+		`{{ tryToInt "7" }}`:                         "7",
+		`{{ tryParseDuration "soon" }}`:              "0",
+		`{{ toYAML (dict "a" 1) | trim }}`:           "a: 1",
+		`{{ (tryFromYAML "a: [1, 2]").a | toJSON }}`: "[1,2]",
+		`{{ downTo 5 (tryToInt "x") }}`:              "5",
+		`{{ toTOML (dict "a" 1) | trim }}`:           "a = 1",
+		`{{ tryFromTOML "a = " | default "none" }}`:  "none",
+		`{{ tryFromYAML ":\n  - [" }}`:               "<no value>",
+		`{{ tryToJSON (list 1) }}`:                   "[1]",
+		`{{ tryToYAML (dict "a" 1) | trim }}`:        "a: 1",
+		`{{ tryToTOML (dict "a" 1) | trim }}`:        "a = 1",
+		`{{ tryParseDuration "1m" }}`:                "60000000000",
+	}
+	for text, want := range cases {
+		out, err := tmpl.EvaluateTemplate(text, nil)
+		assert.NoError(t, err, text)
+		assert.Equal(t, want, out, text)
+	}
+}
+
+func TestFuncs_ErrorsInsteadOfPanics(t *testing.T) {
+	for _, text := range []string{
+		`{{ fromJSON "{" }}`,
+		// The plain form already fails, there is no must alias.
+		`{{ mustFromJSON "{}" }}`,
+		`{{ fromYAML ":\n  - [" }}`,
+		`{{ fromTOML "a = " }}`,
+		`{{ fromTOML "1\nname = 2" }}`,
+		`{{ toInt "twelve" }}`,
+		`{{ upTo "x" 1 }}`,
+		`{{ parseDuration "soon" }}`,
+	} {
+		_, err := tmpl.EvaluateTemplate(text, nil)
+		assert.Error(t, err, text)
+		assert.Contains(t, err.Error(), "template:", text)
+	}
+}
@@ -0,0 +1,243 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	stepKey stepKind = iota
+	stepIndex
+	stepFilter
+	stepAll
+)
+
+type queryStep struct {
+	kind  stepKind
+	key   string
+	index int
+	// filter holds the path compared against value by stepFilter steps.
+	filter []queryStep
+	value  string
+}
+
+// Query returns the value at path in data, walking maps, structs, slices and pointers.
+//
+// A path is made of `.key` (or `["key"]`), `[index]` with negative indexes counting from the end,
+// `[key=value]` picking the first element whose key (itself a path, e.g. `[meta.name=api]`) formats
+// as value, and `[*]` applying the rest of the path to every element, e.g. `.services[name=api].url`
+// or `.services[*].ports[0]`. A path that does not resolve yields nil, malformed paths an error.
+func Query(path string, data any) (any, error) {
+	steps, err := parseQuery(path)
+	if err != nil {
+		return nil, err
+	}
+	return runQuery(steps, reflect.ValueOf(data)), nil
+}
+
+func parseQuery(path string) ([]queryStep, error) {
+	steps := make([]queryStep, 0)
+	rest := strings.TrimSpace(path)
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end > 0 {
+				steps = append(steps, queryStep{kind: stepKey, key: rest[:end]})
+			}
+			rest = rest[end:]
+		case '[':
+			end := closingBracket(rest)
+			if end < 0 {
+				return nil, fmt.Errorf("invalid query %q: unclosed `[`", path)
+			}
+			step, err := parseBracket(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid query %q: %w", path, err)
+			}
+			steps = append(steps, step)
+			rest = rest[end+1:]
+		default:
+			if len(steps) != 0 {
+				return nil, fmt.Errorf("invalid query %q: expected `.` or `[` at %q", path, rest)
+			}
+			rest = "." + rest
+		}
+	}
+	return steps, nil
+}
+
+// closingBracket returns the index of the `]` closing the `[` s starts with, skipping quoted text.
+func closingBracket(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+func parseBracket(inner string) (queryStep, error) {
+	inner = strings.TrimSpace(inner)
+	switch {
+	case inner == "*":
+		return queryStep{kind: stepAll}, nil
+	case strings.HasPrefix(inner, `"`) || strings.HasPrefix(inner, "'"):
+		key, err := unquoteQuery(inner)
+		return queryStep{kind: stepKey, key: key}, err
+	}
+	if name, value, ok := strings.Cut(inner, "="); ok {
+		filter, err := parseQuery(strings.TrimSpace(name))
+		if err != nil {
+			return queryStep{}, err
+		}
+		value, err = unquoteQuery(strings.TrimSpace(value))
+		return queryStep{kind: stepFilter, filter: filter, value: value}, err
+	}
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return queryStep{}, fmt.Errorf("invalid index %q", inner)
+	}
+	return queryStep{kind: stepIndex, index: index}, nil
+}
+
+func unquoteQuery(s string) (string, error) {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1], nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		return strconv.Unquote(s)
+	}
+	return s, nil
+}
+
+func runQuery(steps []queryStep, val reflect.Value) any {
+	for i, step := range steps {
+		val = indirect(val)
+		if !val.IsValid() {
+			return nil
+		}
+		switch step.kind {
+		case stepKey:
+			val = queryKey(val, step.key)
+		case stepIndex:
+			val = queryIndex(val, step.index)
+		case stepFilter:
+			val = queryFilter(val, step)
+		case stepAll:
+			if val.Kind() != reflect.Slice && val.Kind() != reflect.Array && val.Kind() != reflect.Map {
+				return nil
+			}
+			results := make([]any, 0)
+			for _, item := range elements(val) {
+				if result := runQuery(steps[i+1:], item); result != nil {
+					results = append(results, result)
+				}
+			}
+			return results
+		}
+	}
+	val = indirect(val)
+	if !val.IsValid() {
+		return nil
+	}
+	return val.Interface()
+}
+
+func indirect(val reflect.Value) reflect.Value {
+	for val.IsValid() && (val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface) {
+		if val.IsNil() {
+			return reflect.Value{}
+		}
+		val = val.Elem()
+	}
+	return val
+}
+
+func queryKey(val reflect.Value, key string) reflect.Value {
+	switch val.Kind() {
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}
+		}
+		return val.MapIndex(reflect.ValueOf(key).Convert(val.Type().Key()))
+	case reflect.Struct:
+		field, ok := val.Type().FieldByName(key)
+		if !ok || !field.IsExported() {
+			return reflect.Value{}
+		}
+		// The field may be promoted through a nil embedded pointer.
+		value, err := val.FieldByIndexErr(field.Index)
+		if err != nil {
+			return reflect.Value{}
+		}
+		return value
+	default:
+		return reflect.Value{}
+	}
+}
+
+func queryIndex(val reflect.Value, index int) reflect.Value {
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return reflect.Value{}
+	}
+	if index < 0 {
+		index += val.Len()
+	}
+	if index < 0 || index >= val.Len() {
+		return reflect.Value{}
+	}
+	return val.Index(index)
+}
+
+func queryFilter(val reflect.Value, step queryStep) reflect.Value {
+	for _, item := range elements(val) {
+		if got := runQuery(step.filter, item); got != nil && fmt.Sprint(got) == step.value {
+			return item
+		}
+	}
+	return reflect.Value{}
+}
+
+// elements returns the elements of a slice or array, or the values of a map in key order.
+func elements(val reflect.Value) []reflect.Value {
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]reflect.Value, val.Len())
+		for i := range items {
+			items[i] = val.Index(i)
+		}
+		return items
+	case reflect.Map:
+		keys := val.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+		})
+		items := make([]reflect.Value, len(keys))
+		for i, key := range keys {
+			items[i] = val.MapIndex(key)
+		}
+		return items
+	default:
+		return nil
+	}
+}
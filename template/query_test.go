@@ -0,0 +1,66 @@
+package template_test
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	tmpl "github.com/fmotalleb/go-tools/template"
+)
+
+type queryBackend struct {
+	Name string
+	Meta map[string]string
+}
+
+func TestQuery(t *testing.T) {
+	data := &struct {
+		Backends []queryBackend
+		Labels   map[string]any
+	}{
+		Backends: []queryBackend{
+			{Name: "a", Meta: map[string]string{"zone": "eu"}},
+			{Name: "b", Meta: map[string]string{"zone": "us"}},
+		},
+		Labels: map[string]any{"app.kubernetes.io/name": "api"},
+	}
+	cases := map[string]any{
+		".Backends[Meta.zone=us].Name":      "b",
+		"Backends[0].Meta.zone":             "eu",
+		`.Labels["app.kubernetes.io/name"]`: "api",
+		".Backends[*].Meta.zone":            []any{"eu", "us"},
+		".Missing.Name":                     nil,
+		".Backends[5]":                      nil,
+	}
+	for path, want := range cases {
+		got, err := tmpl.Query(path, data)
+		assert.NoError(t, err, path)
+		assert.Equal(t, want, got, path)
+	}
+
+	whole, err := tmpl.Query(".", data)
+	assert.NoError(t, err)
+	assert.Equal(t, any(*data), whole)
+
+	for _, path := range []string{".a[", ".a[x]", `.a["x]`} {
+		_, err := tmpl.Query(path, data)
+		assert.Error(t, err, path)
+	}
+}
+
+type QueryOwner struct {
+	Team string
+}
+
+func TestQuery_NilEmbeddedPointer(t *testing.T) {
+	data := struct {
+		*QueryOwner
+		Name string
+	}{Name: "api"}
+	got, err := tmpl.Query(".Team", data)
+	assert.NoError(t, err)
+	assert.Zero(t, got)
+
+	out, err := tmpl.EvaluateTemplate(`{{ query ".Team" . | default "none" }}`, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "none", out)
+}
@@ -0,0 +1,295 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/fmotalleb/go-tools/matcher"
+)
+
+// DefaultTemplateSuffix marks the files [RenderDir] renders.
+const DefaultTemplateSuffix = ".tmpl"
+
+// dirWriteMode is the mode of directories while their content is written.
+const dirWriteMode fs.FileMode = 0o700
+
+// RenderOptions configures [RenderDir].
+type RenderOptions struct {
+	// Engine renders file contents and names (Default [Default]).
+	Engine *Engine
+	// Suffix marks template files, it is stripped from their output name (Default [DefaultTemplateSuffix]).
+	Suffix string
+	// Skip holds [matcher.Matcher] patterns of source paths to leave out, matched against the slash
+	// separated path relative to the source root and against the base name, e.g. `*.bak`, `glob:secrets/*`
+	// or `regex:^tmp-`. A bare pattern is a wildcard, where `*` spans slashes.
+	Skip []string
+	// PreserveDirModes gives created directories their exact source permissions. By default the owner
+	// write bit is added, so trees from read-only sources such as embed.FS stay writable.
+	PreserveDirModes bool
+	// DryRun renders without writing, reporting the changes as diffs against the existing output.
+	DryRun bool
+	// Diff receives the diff of every changed file when DryRun is set.
+	Diff io.Writer
+}
+
+// RenderedFile describes a file produced by [RenderDir].
+type RenderedFile struct {
+	// Source is the slash separated path in the source FS.
+	Source string
+	// Target is the path written, under the destination directory.
+	Target string
+	// Mode is the permission of the source file, kept on the target.
+	Mode fs.FileMode
+	// Templated reports whether the file was rendered rather than copied.
+	Templated bool
+	// Changed reports whether the target differs from the existing file.
+	Changed bool
+	// Diff is the unified diff against the existing file, set in dry run mode only.
+	Diff string
+}
+
+// RenderDir renders the tree of srcFS into dstDir against data. Files ending in the template suffix are
+// rendered with it stripped, other files are copied verbatim; both keep their permissions. Names of files
+// and directories are templates too (`{{ .Env }}.yaml`), a name rendering empty leaves the entry out.
+// Directories it creates, empty ones included, get their source permissions once their content is written,
+// see [RenderOptions.PreserveDirModes]; existing directories are left as they are.
+// Each file is written atomically through a temporary file and a rename.
+func RenderDir(ctx context.Context, srcFS fs.FS, dstDir string, data any, opts RenderOptions) ([]RenderedFile, error) {
+	r, err := newRenderer(srcFS, dstDir, data, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = fs.WalkDir(srcFS, ".", func(src string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if src == "." {
+			return r.makeRoot()
+		}
+		return r.visit(src, entry)
+	})
+	if err == nil {
+		err = r.chmodDirs()
+	}
+	if err != nil {
+		return r.files, errors.Join(fmt.Errorf("failed to render %s", dstDir), err)
+	}
+	return r.files, nil
+}
+
+type renderer struct {
+	srcFS  fs.FS
+	dstDir string
+	data   any
+	opts   RenderOptions
+	skip   []*matcher.Matcher
+	// targets maps source directories to their rendered target directories.
+	targets map[string]string
+	// dirs holds the created directories, parents first, to get their source mode after the walk.
+	dirs  []RenderedFile
+	files []RenderedFile
+}
+
+func newRenderer(srcFS fs.FS, dstDir string, data any, opts RenderOptions) (*renderer, error) {
+	if opts.Engine == nil {
+		opts.Engine = Default()
+	}
+	if opts.Suffix == "" {
+		opts.Suffix = DefaultTemplateSuffix
+	}
+	r := &renderer{
+		srcFS:   srcFS,
+		dstDir:  dstDir,
+		data:    data,
+		opts:    opts,
+		targets: map[string]string{".": dstDir},
+	}
+	for _, pattern := range opts.Skip {
+		m := new(matcher.Matcher)
+		if _, err := m.Decode(nil, pattern); err != nil {
+			return nil, fmt.Errorf("invalid skip pattern %q: %w", pattern, err)
+		}
+		r.skip = append(r.skip, m)
+	}
+	return r, nil
+}
+
+func (r *renderer) visit(src string, entry fs.DirEntry) error {
+	if r.skipped(src) {
+		if entry.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
+	}
+	parent, ok := r.targets[path.Dir(src)]
+	if !ok {
+		return nil
+	}
+	name, err := r.renderName(entry.Name())
+	if err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+	if name == "" {
+		if entry.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
+	}
+	if entry.IsDir() {
+		r.targets[src] = filepath.Join(parent, name)
+		return r.makeDir(src, r.targets[src])
+	}
+	return r.renderFile(src, filepath.Join(parent, name))
+}
+
+// makeRoot creates the destination directory if missing.
+func (r *renderer) makeRoot() error {
+	if r.opts.DryRun {
+		return nil
+	}
+	return os.MkdirAll(r.dstDir, 0o755)
+}
+
+// makeDir creates target writable by the owner, its source mode is applied by chmodDirs,
+// so a read-only source directory still gets its content.
+func (r *renderer) makeDir(src, target string) error {
+	if r.opts.DryRun {
+		return nil
+	}
+	info, err := fs.Stat(r.srcFS, src)
+	if err != nil {
+		return err
+	}
+	if err := os.Mkdir(target, dirWriteMode); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return nil
+		}
+		return err
+	}
+	mode := info.Mode().Perm()
+	if !r.opts.PreserveDirModes {
+		mode |= 0o200
+	}
+	r.dirs = append(r.dirs, RenderedFile{Source: src, Target: target, Mode: mode})
+	return nil
+}
+
+// chmodDirs applies the source modes to the directories created by this call, children first.
+func (r *renderer) chmodDirs() error {
+	for i := len(r.dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(r.dirs[i].Target, r.dirs[i].Mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *renderer) skipped(src string) bool {
+	for _, m := range r.skip {
+		if m.Match(src) || m.Match(path.Base(src)) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderName renders a file or directory name, leaving names without actions untouched.
+func (r *renderer) renderName(name string) (string, error) {
+	left := r.opts.Engine.leftDelim
+	if left == "" {
+		left = "{{"
+	}
+	if !strings.Contains(name, left) {
+		return name, nil
+	}
+	rendered, err := r.opts.Engine.Evaluate(name, r.data)
+	if err != nil {
+		return "", err
+	}
+	if strings.ContainsAny(rendered, `/\`) || rendered == "." || rendered == ".." {
+		return "", fmt.Errorf("name %q rendered to invalid name %q", name, rendered)
+	}
+	return rendered, nil
+}
+
+func (r *renderer) renderFile(src, target string) error {
+	info, err := fs.Stat(r.srcFS, src)
+	if err != nil {
+		return err
+	}
+	content, err := fs.ReadFile(r.srcFS, src)
+	if err != nil {
+		return err
+	}
+	file := RenderedFile{Source: src, Target: target, Mode: info.Mode().Perm()}
+	if base := filepath.Base(target); strings.HasSuffix(base, r.opts.Suffix) && len(base) > len(r.opts.Suffix) {
+		rendered, err := r.opts.Engine.Evaluate(string(content), r.data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", src, err)
+		}
+		content = []byte(rendered)
+		file.Target = strings.TrimSuffix(target, r.opts.Suffix)
+		file.Templated = true
+	}
+	existing, err := os.ReadFile(file.Target)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	file.Changed = err != nil || !bytes.Equal(existing, content)
+	if r.opts.DryRun {
+		if file.Changed {
+			file.Diff = unifiedDiff(string(existing), string(content), file.Target)
+			if r.opts.Diff != nil {
+				if _, err := io.WriteString(r.opts.Diff, file.Diff); err != nil {
+					return err
+				}
+			}
+		}
+	} else if err := writeAtomic(file.Target, content, file.Mode); err != nil {
+		return err
+	}
+	r.files = append(r.files, file)
+	return nil
+}
+
+// writeAtomic writes content to a temporary file next to target and renames it over target.
+func writeAtomic(target string, content []byte, mode fs.FileMode) error {
+	dir := filepath.Dir(target)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	cleanup := func(err error) error {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return cleanup(err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package template_test
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/alecthomas/assert/v2"
+	tmpl "github.com/fmotalleb/go-tools/template"
+)
+
+func renderSource() fstest.MapFS {
+	return fstest.MapFS{
+		"config.yaml.tmpl":                {Data: []byte("name: {{ .Name }}\nport: 80\n"), Mode: 0o640},
+		"bin/run.sh":                      {Data: []byte("#!/bin/sh\n{{ not rendered }}\n"), Mode: 0o755},
+		"{{ .Env }}/values.yaml.tmpl":     {Data: []byte("env: {{ .Env }}\n"), Mode: 0o644},
+		`{{ if .Debug }}debug{{ end }}/x`: {Data: []byte("x"), Mode: 0o644},
+		"notes.bak":                       {Data: []byte("skip"), Mode: 0o644},
+	}
+}
+
+func TestRenderDir(t *testing.T) {
+	dst := t.TempDir()
+	data := map[string]any{"Name": "api", "Env": "prod", "Debug": false}
+	files, err := tmpl.RenderDir(context.Background(), renderSource(), dst, data, tmpl.RenderOptions{Skip: []string{"*.bak"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(files))
+
+	content, err := os.ReadFile(filepath.Join(dst, "config.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "name: api\nport: 80\n", string(content))
+	info, err := os.Stat(filepath.Join(dst, "config.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+
+	content, err = os.ReadFile(filepath.Join(dst, "bin", "run.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\n{{ not rendered }}\n", string(content))
+	info, err = os.Stat(filepath.Join(dst, "bin", "run.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	content, err = os.ReadFile(filepath.Join(dst, "prod", "values.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "env: prod\n", string(content))
+
+	for _, missing := range []string{"notes.bak", "debug", "config.yaml.tmpl"} {
+		_, err := os.Stat(filepath.Join(dst, missing))
+		assert.True(t, os.IsNotExist(err), missing)
+	}
+	entries, err := os.ReadDir(dst)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(entries))
+}
+
+func TestRenderDir_DryRun(t *testing.T) {
+	dst := t.TempDir()
+	data := map[string]any{"Name": "api", "Env": "prod", "Debug": false}
+	_, err := tmpl.RenderDir(context.Background(), renderSource(), dst, data, tmpl.RenderOptions{})
+	assert.NoError(t, err)
+
+	diff := new(bytes.Buffer)
+	data["Name"] = "web"
+	files, err := tmpl.RenderDir(context.Background(), renderSource(), dst, data, tmpl.RenderOptions{DryRun: true, Diff: diff})
+	assert.NoError(t, err)
+	changed := 0
+	for _, file := range files {
+		if file.Changed {
+			changed++
+		}
+	}
+	assert.Equal(t, 1, changed)
+	target := filepath.Join(dst, "config.yaml")
+	assert.Equal(t, "--- "+target+"\n+++ "+target+"\n@@ -1,2 +1,2 @@\n-name: api\n+name: web\n port: 80\n", diff.String())
+
+	content, err := os.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "name: api\nport: 80\n", string(content))
+}
+
+func TestRenderDir_Dirs(t *testing.T) {
+	src := fstest.MapFS{
+		"conf":           {Mode: fs.ModeDir | 0o750},
+		"conf/app.yaml":  {Data: []byte("a"), Mode: 0o600},
+		"empty":          {Mode: fs.ModeDir | 0o711},
+		"locked":         {Mode: fs.ModeDir | 0o555},
+		"locked/ro.txt":  {Data: []byte("ro"), Mode: 0o444},
+		"tmp-cache":      {Mode: fs.ModeDir | 0o755},
+		"tmp-cache/x":    {Data: []byte("x"), Mode: 0o644},
+		"secrets/db.key": {Data: []byte("key"), Mode: 0o600},
+	}
+	dst := filepath.Join(t.TempDir(), "out")
+	// Existing directories keep their mode.
+	assert.NoError(t, os.MkdirAll(filepath.Join(dst, "empty"), 0o700))
+	skip := []string{"regex:^tmp-", "glob:secrets/*"}
+	files, err := tmpl.RenderDir(context.Background(), src, dst, nil, tmpl.RenderOptions{Skip: skip})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(files))
+
+	for dir, mode := range map[string]os.FileMode{"conf": 0o750, "empty": 0o700, "locked": 0o755, "secrets": 0o755} {
+		info, err := os.Stat(filepath.Join(dst, dir))
+		assert.NoError(t, err, dir)
+		assert.True(t, info.IsDir(), dir)
+		assert.Equal(t, mode, info.Mode().Perm(), dir)
+	}
+	content, err := os.ReadFile(filepath.Join(dst, "locked", "ro.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ro", string(content))
+	for _, missing := range []string{"tmp-cache", filepath.Join("secrets", "db.key")} {
+		_, err := os.Stat(filepath.Join(dst, missing))
+		assert.True(t, os.IsNotExist(err), missing)
+	}
+	_, err = tmpl.RenderDir(context.Background(), src, dst, nil, tmpl.RenderOptions{Skip: skip})
+	assert.NoError(t, err)
+
+	exact := filepath.Join(t.TempDir(), "exact")
+	_, err = tmpl.RenderDir(context.Background(), src, exact, nil, tmpl.RenderOptions{Skip: skip, PreserveDirModes: true})
+	t.Cleanup(func() {
+		// Let the temp dir cleanup remove the content of read-only directories.
+		_ = os.Chmod(filepath.Join(exact, "locked"), 0o700)
+		_ = os.Chmod(filepath.Join(exact, "secrets"), 0o700)
+	})
+	assert.NoError(t, err)
+	for dir, mode := range map[string]os.FileMode{"conf": 0o750, "empty": 0o711, "locked": 0o555, "secrets": 0o555} {
+		info, err := os.Stat(filepath.Join(exact, dir))
+		assert.NoError(t, err, dir)
+		assert.Equal(t, mode, info.Mode().Perm(), dir)
+	}
+
+	dryDst := filepath.Join(t.TempDir(), "dry")
+	_, err = tmpl.RenderDir(context.Background(), src, dryDst, nil, tmpl.RenderOptions{DryRun: true})
+	assert.NoError(t, err)
+	_, err = os.Stat(dryDst)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRenderDir_Errors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := tmpl.RenderDir(ctx, renderSource(), t.TempDir(), nil, tmpl.RenderOptions{})
+	assert.IsError(t, err, context.Canceled)
+
+	src := fstest.MapFS{"{{ .Name }}": {Data: []byte("x")}}
+	_, err = tmpl.RenderDir(context.Background(), src, t.TempDir(), map[string]string{"Name": "../escape"}, tmpl.RenderOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,406 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+var (
+	// ErrOutputLimit is returned when a sandboxed template writes more than [Sandbox.MaxOutput] bytes.
+	ErrOutputLimit = errors.New("template output limit exceeded")
+	// ErrTimeout is returned when a sandboxed template runs longer than [Sandbox.Timeout].
+	ErrTimeout = errors.New("template execution timed out")
+	// ErrSizeLimit is returned when a sandboxed func would build a value larger than [Sandbox.MaxSize].
+	ErrSizeLimit = errors.New("template value size limit exceeded")
+	// ErrIterationLimit is returned when a sandboxed template builds more than [Sandbox.MaxIterations]
+	// elements in total.
+	ErrIterationLimit = errors.New("template iteration limit exceeded")
+)
+
+const (
+	// DefaultSandboxMaxSize is the [Sandbox.MaxSize] used when it is not set.
+	DefaultSandboxMaxSize = 1 << 20
+	// DefaultSandboxMaxIterations is the [Sandbox.MaxIterations] used when it is not set.
+	DefaultSandboxMaxIterations = 1 << 20
+)
+
+// Sandbox restricts what a template can reach, for templates from untrusted sources such as tenants.
+// The zero value denies every file and env access, caps values built from a count at
+// [DefaultSandboxMaxSize], the elements built by all of them at [DefaultSandboxMaxIterations]
+// and sets no output or time limits.
+type Sandbox struct {
+	// FileRoots are the directories `file` may read from; symlinks escaping them are refused.
+	FileRoots []string
+	// EnvNames are the variables `env` and `expandenv` may read.
+	EnvNames []string
+	// EnvPrefixes allow every variable starting with one of them, e.g. `APP_`.
+	EnvPrefixes []string
+	// MaxOutput limits the output size in bytes, 0 means no limit.
+	MaxOutput int
+	// MaxSize limits the elements or bytes of values built from a count, which never reach the output
+	// limit when kept in variables: `until`, `untilStep`, `seq`, `repeat`, `indent`, `nindent`,
+	// `randAlpha`, `randAlphaNum`, `randAscii`, `randNumeric` and `randBytes` (Default [DefaultSandboxMaxSize]).
+	MaxSize int
+	// MaxIterations limits the elements `until`, `untilStep` and `seq` build over a whole execution,
+	// which bounds nested loops over them (Default [DefaultSandboxMaxIterations]).
+	MaxIterations int
+	// Timeout limits the execution time, 0 means no limit. Once it passes, every func call and write
+	// of the execution fails, ending it; only a template calling no func and writing nothing keeps
+	// running until it returns.
+	Timeout time.Duration
+}
+
+// WithSandbox restricts every template of the engine to sandbox.
+func WithSandbox(sandbox Sandbox) EngineOption {
+	return func(e *Engine) {
+		e.sandbox = &sandbox
+	}
+}
+
+// EvaluateSandboxed does what [Engine.Evaluate] does, restricting this call to sandbox instead of
+// the engine's own sandbox, if any.
+func (e *Engine) EvaluateSandboxed(text string, data any, sandbox Sandbox) (string, error) {
+	tmpl, err := e.Parse(text)
+	if err != nil {
+		return "", err
+	}
+	output := new(strings.Builder)
+	if err := sandbox.execute(output, tmpl, e.funcs, data); err != nil {
+		return "", err
+	}
+	return output.String(), nil
+}
+
+// EvaluateSandboxed executes text against vars restricted to sandbox, using the [Default] engine.
+func EvaluateSandboxed(text string, vars any, sandbox Sandbox) (string, error) {
+	return Default().EvaluateSandboxed(text, vars, sandbox)
+}
+
+// funcs returns the funcs replacing the unrestricted ones, templates are parsed with them.
+// Every execution binds its own copy, see [Sandbox.run].
+func (s Sandbox) funcs() template.FuncMap {
+	return s.execFuncs(s.newExecution())
+}
+
+// execFuncs returns the funcs replacing the unrestricted ones for the execution x.
+func (s Sandbox) execFuncs(x *execution) template.FuncMap {
+	funcs := template.FuncMap{
+		"file":      s.readFile,
+		"env":       s.getenv,
+		"expandenv": s.expandenv,
+		"getHostByName": func(string) (string, error) {
+			return "", errors.New("getHostByName is not allowed in sandbox")
+		},
+	}
+	maps.Copy(funcs, s.sizedFuncs(x))
+	return funcs
+}
+
+// sizedFuncs returns the sprig funcs building a value from a count, refusing counts above MaxSize
+// and, for the element building ones, counts exhausting the iterations left to x.
+func (s Sandbox) sizedFuncs(x *execution) template.FuncMap {
+	limit := s.MaxSize
+	if limit <= 0 {
+		limit = DefaultSandboxMaxSize
+	}
+	check := func(name string, size int) error {
+		if size < 0 || size > limit {
+			return fmt.Errorf("%s: size %d is above %d: %w", name, size, limit, ErrSizeLimit)
+		}
+		return nil
+	}
+	orig := sprig.FuncMap()
+	funcs := template.FuncMap{
+		"until": func(count int) ([]int, error) {
+			if err := check("until", absInt(count)); err != nil {
+				return nil, err
+			}
+			if err := x.iterate("until", absInt(count)); err != nil {
+				return nil, err
+			}
+			return orig["until"].(func(int) []int)(count), nil
+		},
+		"untilStep": func(start, stop, step int) ([]int, error) {
+			if step != 0 {
+				if err := check("untilStep", absInt((stop-start)/step)); err != nil {
+					return nil, err
+				}
+				if err := x.iterate("untilStep", absInt((stop-start)/step)); err != nil {
+					return nil, err
+				}
+			}
+			return orig["untilStep"].(func(int, int, int) []int)(start, stop, step), nil
+		},
+		"seq": func(params ...int) (string, error) {
+			if err := check("seq", seqSize(params)); err != nil {
+				return "", err
+			}
+			if err := x.iterate("seq", seqSize(params)); err != nil {
+				return "", err
+			}
+			return orig["seq"].(func(...int) string)(params...), nil
+		},
+		"repeat": func(count int, str string) (string, error) {
+			if err := check("repeat", count*len(str)); err != nil {
+				return "", err
+			}
+			return strings.Repeat(str, count), nil
+		},
+	}
+	for _, name := range []string{"indent", "nindent"} {
+		indent := orig[name].(func(int, string) string)
+		funcs[name] = func(spaces int, v string) (string, error) {
+			if err := check(name, spaces*(strings.Count(v, "\n")+1)+len(v)); err != nil {
+				return "", err
+			}
+			return indent(spaces, v), nil
+		}
+	}
+	for _, name := range []string{"randAlpha", "randAlphaNum", "randAscii", "randNumeric"} {
+		random := orig[name].(func(int) string)
+		funcs[name] = func(count int) (string, error) {
+			if err := check(name, count); err != nil {
+				return "", err
+			}
+			return random(count), nil
+		}
+	}
+	randBytes := orig["randBytes"].(func(int) (string, error))
+	funcs["randBytes"] = func(count int) (string, error) {
+		if err := check("randBytes", count); err != nil {
+			return "", err
+		}
+		return randBytes(count)
+	}
+	return funcs
+}
+
+// seqSize returns the number of elements sprig's seq builds for params.
+func seqSize(params []int) int {
+	switch len(params) {
+	case 1:
+		return absInt(params[0])
+	case 2:
+		return absInt(params[1]-params[0]) + 1
+	case 3:
+		if params[1] == 0 {
+			return 0
+		}
+		return absInt((params[2]-params[0])/params[1]) + 1
+	default:
+		return 0
+	}
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (s Sandbox) readFile(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	for _, dir := range s.FileRoots {
+		rootDir, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(rootDir, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		// os.Root refuses symlinks and `..` escaping the directory.
+		root, err := os.OpenRoot(rootDir)
+		if err != nil {
+			return "", err
+		}
+		data, err := root.ReadFile(rel)
+		root.Close()
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return "", fmt.Errorf("file %q is outside the sandbox roots", path)
+}
+
+func (s Sandbox) envAllowed(name string) bool {
+	if slices.Contains(s.EnvNames, name) {
+		return true
+	}
+	for _, prefix := range s.EnvPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Sandbox) getenv(name string) (string, error) {
+	if !s.envAllowed(name) {
+		return "", fmt.Errorf("env %q is not allowed in sandbox", name)
+	}
+	return os.Getenv(name), nil
+}
+
+func (s Sandbox) expandenv(str string) (string, error) {
+	var denied []string
+	result := os.Expand(str, func(name string) string {
+		if !s.envAllowed(name) {
+			denied = append(denied, name)
+			return ""
+		}
+		return os.Getenv(name)
+	})
+	if len(denied) != 0 {
+		return "", fmt.Errorf("env %s not allowed in sandbox", strings.Join(denied, ", "))
+	}
+	return result, nil
+}
+
+// execute runs tmpl, parsed with funcs, against data writing to w within the limits of the sandbox.
+func (s *Sandbox) execute(w io.Writer, tmpl *template.Template, funcs template.FuncMap, data any) error {
+	return s.run(w, tmpl, funcs, func(t *template.Template, out io.Writer) error {
+		return t.Execute(out, data)
+	})
+}
+
+// run calls exec with a copy of tmpl whose funcs, taken from funcs and the sandbox, are bound to
+// this execution, so they stop working once it times out.
+func (s *Sandbox) run(
+	w io.Writer,
+	tmpl *template.Template,
+	funcs template.FuncMap,
+	exec func(*template.Template, io.Writer) error,
+) error {
+	if s == nil {
+		return wrapExecErr(exec(tmpl, w))
+	}
+	x := s.newExecution()
+	bound, err := tmpl.Clone()
+	if err != nil {
+		return fmt.Errorf("failed to prepare sandbox: %w", err)
+	}
+	bound.Funcs(x.guard(funcs, s.execFuncs(x)))
+	out := &guardedWriter{w: w, limit: s.MaxOutput}
+	if s.Timeout <= 0 {
+		return wrapExecErr(exec(bound, out))
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- exec(bound, out)
+	}()
+	timer := time.NewTimer(s.Timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return wrapExecErr(err)
+	case <-timer.C:
+		x.stop()
+		out.stop(ErrTimeout)
+		return wrapExecErr(ErrTimeout)
+	}
+}
+
+// execution holds the state a single sandboxed execution shares between its funcs.
+type execution struct {
+	stopped atomic.Bool
+	// iterations left to `until`, `untilStep` and `seq`, only used by the executing goroutine.
+	iterations int
+}
+
+func (s Sandbox) newExecution() *execution {
+	iterations := s.MaxIterations
+	if iterations <= 0 {
+		iterations = DefaultSandboxMaxIterations
+	}
+	return &execution{iterations: iterations}
+}
+
+func (x *execution) stop() {
+	x.stopped.Store(true)
+}
+
+// iterate takes count elements from the iterations left.
+func (x *execution) iterate(name string, count int) error {
+	if count > x.iterations {
+		return fmt.Errorf("%s: %d elements exceed the %d left: %w", name, count, x.iterations, ErrIterationLimit)
+	}
+	x.iterations -= count
+	return nil
+}
+
+// guard merges the funcs maps, later ones winning, and wraps every func to fail once x is stopped.
+// text/template turns the panic into an execution error, whatever the func returns.
+func (x *execution) guard(funcMaps ...template.FuncMap) template.FuncMap {
+	guarded := make(template.FuncMap)
+	for _, funcs := range funcMaps {
+		for name, fn := range funcs {
+			v := reflect.ValueOf(fn)
+			guarded[name] = reflect.MakeFunc(v.Type(), func(args []reflect.Value) []reflect.Value {
+				if x.stopped.Load() {
+					panic(ErrTimeout)
+				}
+				if v.Type().IsVariadic() {
+					return v.CallSlice(args)
+				}
+				return v.Call(args)
+			}).Interface()
+		}
+	}
+	return guarded
+}
+
+func wrapExecErr(err error) error {
+	if err != nil {
+		return fmt.Errorf("failed to execute template: %w", err)
+	}
+	return nil
+}
+
+// guardedWriter enforces the output limit and refuses writes once stopped.
+type guardedWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	limit   int
+	written int
+	err     error
+}
+
+func (g *guardedWriter) Write(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.err != nil {
+		return 0, g.err
+	}
+	if g.limit > 0 && g.written+len(p) > g.limit {
+		g.err = ErrOutputLimit
+		return 0, g.err
+	}
+	n, err := g.w.Write(p)
+	g.written += n
+	return n, err
+}
+
+func (g *guardedWriter) stop(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.err = err
+}
@@ -0,0 +1,122 @@
+package template_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+	tmpl "github.com/fmotalleb/go-tools/template"
+)
+
+func TestSandbox_Files(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "ok.txt"), []byte("allowed"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o600))
+	assert.NoError(t, os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "link.txt")))
+
+	sandbox := tmpl.Sandbox{FileRoots: []string{root}}
+	out, err := tmpl.EvaluateSandboxed(`{{ file .Path }}`, map[string]string{"Path": filepath.Join(root, "ok.txt")}, sandbox)
+	assert.NoError(t, err)
+	assert.Equal(t, "allowed", out)
+
+	for _, path := range []string{
+		filepath.Join(outside, "secret.txt"),
+		filepath.Join(root, "..", filepath.Base(outside), "secret.txt"),
+		filepath.Join(root, "link.txt"),
+	} {
+		_, err := tmpl.EvaluateSandboxed(`{{ file .Path }}`, map[string]string{"Path": path}, sandbox)
+		assert.Error(t, err, path)
+	}
+
+	// The unrestricted engine is left untouched by per-call sandboxes.
+	out, err = tmpl.EvaluateTemplate(`{{ file .Path }}`, map[string]string{"Path": filepath.Join(outside, "secret.txt")})
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", out)
+}
+
+func TestSandbox_Env(t *testing.T) {
+	t.Setenv("TENANT_NAME", "acme")
+	t.Setenv("SECRET_TOKEN", "hunter2")
+	e, err := tmpl.NewEngine(tmpl.WithSandbox(tmpl.Sandbox{EnvPrefixes: []string{"TENANT_"}}))
+	assert.NoError(t, err)
+
+	out, err := e.Evaluate(`{{ env "TENANT_NAME" }} {{ expandenv "$TENANT_NAME" }}`, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "acme acme", out)
+
+	for _, text := range []string{`{{ env "SECRET_TOKEN" }}`, `{{ expandenv "${SECRET_TOKEN}" }}`, `{{ file "/etc/hostname" }}`} {
+		_, err := e.Evaluate(text, nil)
+		assert.Error(t, err, text)
+	}
+	_, err = e.EvaluateWithFuncs(`{{ env "SECRET_TOKEN" }}`, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestSandbox_Limits(t *testing.T) {
+	_, err := tmpl.EvaluateSandboxed(`{{ repeat 100 "x" }}`, nil, tmpl.Sandbox{MaxOutput: 10})
+	assert.IsError(t, err, tmpl.ErrOutputLimit)
+
+	out, err := tmpl.EvaluateSandboxed(`{{ repeat 10 "x" }}`, nil, tmpl.Sandbox{MaxOutput: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("x", 10), out)
+
+	start := time.Now()
+	_, err = tmpl.EvaluateSandboxed(`{{ range until 1000000 }}{{ range until 1000000 }}{{ end }}{{ end }}`, nil, tmpl.Sandbox{})
+	assert.IsError(t, err, tmpl.ErrIterationLimit)
+	assert.True(t, time.Since(start) < time.Second)
+
+	_, err = tmpl.EvaluateSandboxed(`{{ range until 10 }}{{ range until 10 }}{{ end }}{{ end }}`, nil, tmpl.Sandbox{MaxIterations: 100})
+	assert.IsError(t, err, tmpl.ErrIterationLimit)
+	_, err = tmpl.EvaluateSandboxed(`{{ range until 9 }}{{ range until 10 }}{{ end }}{{ end }}`, nil, tmpl.Sandbox{MaxIterations: 100})
+	assert.NoError(t, err)
+}
+
+func TestSandbox_TimeoutStopsExecution(t *testing.T) {
+	var calls atomic.Int64
+	e, err := tmpl.NewEngine(tmpl.WithFuncs(template.FuncMap{
+		"tick": func() string {
+			calls.Add(1)
+			time.Sleep(time.Millisecond)
+			return ""
+		},
+	}))
+	assert.NoError(t, err)
+
+	_, err = e.EvaluateSandboxed(`{{ range until 100000 }}{{ tick }}{{ end }}`, nil, tmpl.Sandbox{Timeout: 20 * time.Millisecond})
+	assert.IsError(t, err, tmpl.ErrTimeout)
+
+	// The abandoned execution fails at its next func call and returns.
+	time.Sleep(20 * time.Millisecond)
+	stopped := calls.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, stopped, calls.Load())
+	assert.True(t, stopped < 100000)
+}
+
+func TestSandbox_SizeLimits(t *testing.T) {
+	for _, text := range []string{
+		`{{ $l := until 100000000 }}{{ len $l }}`,
+		`{{ $l := untilStep 0 100000000 1 }}{{ len $l }}`,
+		`{{ $s := seq 1 100000000 }}{{ len $s }}`,
+		`{{ $s := repeat 100000000 "x" }}{{ len $s }}`,
+		`{{ $s := indent 100000000 "x" }}{{ len $s }}`,
+		`{{ $s := randAlphaNum 100000000 }}{{ len $s }}`,
+		`{{ $s := randBytes 100000000 }}{{ len $s }}`,
+	} {
+		_, err := tmpl.EvaluateSandboxed(text, nil, tmpl.Sandbox{})
+		assert.IsError(t, err, tmpl.ErrSizeLimit, text)
+	}
+
+	_, err := tmpl.EvaluateSandboxed(`{{ $l := until 11 }}{{ len $l }}`, nil, tmpl.Sandbox{MaxSize: 10})
+	assert.IsError(t, err, tmpl.ErrSizeLimit)
+
+	out, err := tmpl.EvaluateSandboxed(`{{ len (until 10) }} {{ seq 3 }} {{ repeat 2 "ab" }} {{ nindent 2 "x" }}`, nil, tmpl.Sandbox{MaxSize: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, "10 1 2 3 abab \n  x", out)
+}
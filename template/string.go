@@ -0,0 +1,99 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"text/template"
+)
+
+// String is a template that keeps its source and renders on demand, e.g. a per-request URL or message body.
+//
+// It decodes from a plain string (via encoding.TextUnmarshaler) without evaluating it, so a config
+// can hold templates rendered later against runtime data. The zero value renders as an empty string.
+type String struct {
+	source string
+	parsed *lazyTemplate
+}
+
+type lazyTemplate struct {
+	once sync.Once
+	tmpl *template.Template
+	err  error
+}
+
+// NewString creates a [String] from source; parsing is deferred to the first render.
+func NewString(source string) String {
+	return String{source: source, parsed: new(lazyTemplate)}
+}
+
+// Source returns the template source.
+func (s String) Source() string {
+	return s.source
+}
+
+// String returns the template source.
+func (s String) String() string {
+	return s.source
+}
+
+// IsZero reports whether the template has no source.
+func (s String) IsZero() bool {
+	return s.source == ""
+}
+
+// Validate parses the template with the default funcs and reports syntax errors.
+// Templates that use funcs passed to [String.RenderWithFuncs] fail to validate.
+func (s String) Validate() error {
+	_, err := s.template()
+	return err
+}
+
+// Render executes the template against data. The parsed template is cached, so repeated renders
+// only pay for execution.
+func (s String) Render(data any) (string, error) {
+	tmpl, err := s.template()
+	if err != nil {
+		return "", err
+	}
+	return execute(tmpl, data)
+}
+
+// RenderWithFuncs executes the template against data with funcs added to (or overriding) the default funcs.
+// The template is parsed on every call since the funcs take part in parsing.
+func (s String) RenderWithFuncs(data any, funcs template.FuncMap) (string, error) {
+	tmpl, err := Default().parse(s.source, funcs)
+	if err != nil {
+		return "", err
+	}
+	return execute(tmpl, data)
+}
+
+// MarshalText implements encoding.TextMarshaler, returning the source.
+func (s String) MarshalText() ([]byte, error) {
+	return []byte(s.source), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, storing text as the source without evaluating it.
+func (s *String) UnmarshalText(text []byte) error {
+	*s = NewString(string(text))
+	return nil
+}
+
+func (s String) template() (*template.Template, error) {
+	if s.parsed == nil {
+		return Default().Parse(s.source)
+	}
+	s.parsed.once.Do(func() {
+		s.parsed.tmpl, s.parsed.err = Default().Parse(s.source)
+	})
+	return s.parsed.tmpl, s.parsed.err
+}
+
+func execute(tmpl *template.Template, data any) (string, error) {
+	output := new(bytes.Buffer)
+	if err := tmpl.Execute(output, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return output.String(), nil
+}
@@ -0,0 +1,103 @@
+package template_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/alecthomas/assert/v2"
+	tmpl "github.com/fmotalleb/go-tools/template"
+)
+
+func TestString_Render(t *testing.T) {
+	s := tmpl.NewString("https://{{ .Host }}/users/{{ .ID }}")
+	assert.Equal(t, "https://{{ .Host }}/users/{{ .ID }}", s.Source())
+
+	for _, id := range []int{1, 2} {
+		out, err := s.Render(map[string]any{"Host": "api.example.com", "ID": id})
+		assert.NoError(t, err)
+		assert.Equal(t, "https://api.example.com/users/"+strconv.Itoa(id), out)
+	}
+
+	_, err := s.Render(map[string]any{"Host": "x"})
+	assert.Error(t, err)
+
+	out, err := tmpl.NewString(`{{ shout .Msg }}`).RenderWithFuncs(
+		map[string]any{"Msg": "hi"},
+		template.FuncMap{"shout": strings.ToUpper},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "HI", out)
+
+	assert.Error(t, tmpl.NewString("{{ .Broken").Validate())
+
+	var zero tmpl.String
+	out, err = zero.Render(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "", out)
+}
+
+func TestString_Text(t *testing.T) {
+	var s tmpl.String
+	assert.NoError(t, s.UnmarshalText([]byte("Hello {{ .Name }}")))
+	text, err := s.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello {{ .Name }}", string(text))
+}
+
+type templateRoute struct {
+	URL     string `template:"true"`
+	Literal string
+}
+
+type templateConfig struct {
+	Name    string            `template:"true"`
+	Headers map[string]string `template:"true"`
+	Args    []string          `template:"true"`
+	Token   *string           `template:"true"`
+	Raw     string
+	Routes  []templateRoute
+	ByName  map[string]templateRoute
+	Nested  *templateRoute
+}
+
+func TestEvaluateOnStruct(t *testing.T) {
+	token := "{{ .Token }}"
+	cfg := templateConfig{
+		Name:    "{{ .Name }}-svc",
+		Headers: map[string]string{"X-Env": "{{ .Env }}"},
+		Args:    []string{"--env={{ .Env }}", "plain"},
+		Token:   &token,
+		Raw:     "{{ not evaluated",
+		Routes:  []templateRoute{{URL: "/{{ .Env }}", Literal: "{{ .Env }}"}},
+		ByName:  map[string]templateRoute{"a": {URL: "/a/{{ .Env }}"}},
+		Nested:  &templateRoute{URL: "{{ .Name }}"},
+	}
+	data := map[string]any{"Name": "api", "Env": "prod", "Token": "secret"}
+	assert.NoError(t, tmpl.EvaluateOnStruct(&cfg, data))
+
+	assert.Equal(t, "api-svc", cfg.Name)
+	assert.Equal(t, map[string]string{"X-Env": "prod"}, cfg.Headers)
+	assert.Equal(t, []string{"--env=prod", "plain"}, cfg.Args)
+	assert.Equal(t, "secret", *cfg.Token)
+	assert.Equal(t, "{{ not evaluated", cfg.Raw)
+	assert.Equal(t, templateRoute{URL: "/prod", Literal: "{{ .Env }}"}, cfg.Routes[0])
+	assert.Equal(t, "/a/prod", cfg.ByName["a"].URL)
+	assert.Equal(t, "api", cfg.Nested.URL)
+}
+
+func TestEvaluateOnStruct_Errors(t *testing.T) {
+	cfg := struct {
+		Routes []templateRoute
+		Count  int `template:"true"`
+	}{
+		Routes: []templateRoute{{URL: "{{ .Missing }}"}},
+	}
+	err := tmpl.EvaluateOnStruct(&cfg, map[string]any{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), ".Routes[0].URL")
+	assert.Contains(t, err.Error(), "template field .Count must hold strings")
+
+	assert.Error(t, tmpl.EvaluateOnStruct(cfg, nil))
+}
@@ -1,7 +1,6 @@
 package template
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -44,113 +43,106 @@ func buildFuncMap() template.FuncMap {
 		// "contains":      strings.Contains,
 		// "itoa":          strconv.Itoa,
 		// "atoi":          strconv.Atoi,
-		"toJSON":        toJSON,
-		"fromJSON":      fromJSON,
-		"toYAML":        toYAML,
-		"fromYAML":      fromYAML,
-		"toTOML":        toTOML,
-		"fromTOML":      fromTOML,
-		"toInt":         toInt,
-		"atob":          atob,
-		"matches":       match,
-		"upTo":          upTo,
-		"downTo":        downTo,
-		"file":          readFile,
-		"parseDuration": parseDuration,
+		"atob":    atob,
+		"matches": match,
+		"upTo":    upTo,
+		"downTo":  downTo,
+		"file":    readFile,
+		"query":   Query,
 	}
 
 	maps.Copy(result, internal)
+	maps.Copy(result, netFuncs())
+	addFallible(result, "toJSON", toJSON)
+	addFallible(result, "fromJSON", fromJSON)
+	addFallible(result, "toYAML", toYAML)
+	addFallible(result, "fromYAML", fromYAML)
+	addFallible(result, "toTOML", toTOML)
+	addFallible(result, "fromTOML", fromTOML)
+	addFallible(result, "toInt", toInt)
+	addFallible(result, "parseDuration", parseDuration)
 	return result
 }
 
+// EvaluateTemplate executes text against vars using the [Default] engine, see [Engine.Evaluate].
 func EvaluateTemplate(text string, vars any) (string, error) {
-	templateObj := template.New("template")
-	templateObj = templateObj.Option("missingkey=error")
-	templateObj = templateObj.Funcs(fMap())
-
-	templateObj, err := templateObj.Parse(text)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
-	}
-
-	output := bytes.NewBufferString("")
-	err = templateObj.Execute(output, vars)
-	if err != nil {
-		return "", fmt.Errorf("failed to execute template using vars snapshot: %w", err)
-	}
-	return output.String(), nil
+	return Default().Evaluate(text, vars)
 }
 
+// EvaluateTemplateWithFuncs executes text against vars with funcs added to the default funcs,
+// see [Engine.EvaluateWithFuncs]. Unlike [EvaluateTemplate], missing keys render as `<no value>`.
 func EvaluateTemplateWithFuncs(text string, vars any, funcs template.FuncMap) (string, error) {
-	templateObj := template.New("template")
-	fmap := fMap()
-	for k, v := range funcs {
-		fmap[k] = v
-	}
-	templateObj = templateObj.Funcs(fmap)
-
-	templateObj, err := templateObj.Parse(text)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
-	}
+	return lenientEngine().EvaluateWithFuncs(text, vars, funcs)
+}
 
-	output := bytes.NewBufferString("")
-	err = templateObj.Execute(output, vars)
-	if err != nil {
-		return "", fmt.Errorf("failed to execute template using vars snapshot: %w", err)
+// addFallible registers fn as name, failing the template execution with its error, and as tryName,
+// rendering the zero value instead and dropping the error, for input that may be missing or invalid.
+// name already behaves as sprig's mustName funcs do, so no mustName alias is registered.
+func addFallible[I, O any](funcs template.FuncMap, name string, fn func(I) (O, error)) {
+	funcs[name] = fn
+	funcs["try"+strings.ToUpper(name[:1])+name[1:]] = func(in I) O {
+		out, _ := fn(in)
+		return out
 	}
-	return output.String(), nil
 }
 
-func toJSON(v any) string {
+func toJSON(v any) (string, error) {
 	data, err := json.Marshal(v)
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("failed to encode JSON: %w", err)
 	}
-	return string(data)
+	return string(data), nil
 }
 
-func fromJSON(s string) map[string]any {
-	var result map[string]any
-	err := json.Unmarshal([]byte(s), &result)
-	if err != nil {
-		panic(err)
+func fromJSON(s string) (any, error) {
+	var result any
+	if err := json.Unmarshal([]byte(s), &result); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
 	}
-	return result
+	return result, nil
 }
 
-func toYAML(v any) string {
+func toYAML(v any) (string, error) {
 	data, err := yaml.Marshal(v)
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("failed to encode YAML: %w", err)
 	}
-	return string(data)
+	return string(data), nil
 }
 
-func fromYAML(s string) map[string]any {
-	var result map[string]any
-	err := yaml.Unmarshal([]byte(s), &result)
-	if err != nil {
-		panic(err)
+func fromYAML(s string) (any, error) {
+	var result any
+	if err := yaml.Unmarshal([]byte(s), &result); err != nil {
+		return nil, fmt.Errorf("failed to decode YAML: %w", err)
 	}
-	return result
+	return result, nil
 }
 
-func toTOML(v any) string {
+func toTOML(v any) (string, error) {
 	data, err := toml.Marshal(v)
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("failed to encode TOML: %w", err)
 	}
-	return string(data)
+	return string(data), nil
 }
 
-func fromTOML(s string) map[string]any {
+// fromTOML decodes a TOML document, or a single line TOML value such as `[1, 2]` or `"text"`.
+func fromTOML(s string) (any, error) {
 	var result map[string]any
 	err := toml.Unmarshal([]byte(s), &result)
-	if err != nil {
-		panic(err)
+	if err == nil {
+		return result, nil
 	}
-	return result
+	if strings.ContainsAny(s, "\r\n") {
+		return nil, fmt.Errorf("failed to decode TOML: %w", err)
+	}
+	var value struct {
+		V any `toml:"v"`
+	}
+	if toml.Unmarshal([]byte("v = "+s), &value) == nil {
+		return value.V, nil
+	}
+	return nil, fmt.Errorf("failed to decode TOML: %w", err)
 }
 
 // func b64dec(s string) string {
@@ -172,61 +164,79 @@ func atob(s string) (bool, error) {
 	}
 }
 
-func toInt(v interface{}) int {
+func toInt(v any) (int, error) {
 	switch val := v.(type) {
 	case int:
-		return val
+		return val, nil
 	case int8, int16, int32, int64:
-		return int(reflect.ValueOf(val).Int())
+		return int(reflect.ValueOf(val).Int()), nil
 	case uint, uint8, uint16, uint32, uint64:
 		uval := reflect.ValueOf(val).Uint()
 		if uval > uint64(^uint(0)>>1) {
-			panic(fmt.Errorf("integer overflow: value %d exceeds int range", uval))
+			return 0, fmt.Errorf("integer overflow: value %d exceeds int range", uval)
 		}
-		return int(uval)
+		return int(uval), nil
 	case float32:
-		return int(val)
+		return int(val), nil
 	case float64:
-		return int(val)
+		return int(val), nil
 	case string:
 		if i, err := strconv.Atoi(val); err == nil {
-			return i
+			return i, nil
 		}
 		if f, err := strconv.ParseFloat(val, 64); err == nil {
-			return int(f)
+			return int(f), nil
 		}
-		panic(fmt.Errorf("cannot convert string to int: %s", val))
+		return 0, fmt.Errorf("cannot convert string to int: %s", val)
 	default:
-		panic(fmt.Errorf("unsupported type: %T", val))
+		return 0, fmt.Errorf("unsupported type: %T", val)
 	}
 }
 
+// matchers caches compiled patterns of `matches`, bounded since patterns may come from data.
+var matchers = sync.OnceValue(func() *lru[*matcher.Matcher] {
+	return newLRU[*matcher.Matcher](DefaultCacheSize)
+})
+
 func match(pat, input string) (bool, error) {
-	m := new(matcher.Matcher)
-	var err error
-	_, err = m.Decode(reflect.TypeOf(pat), pat)
-	if err != nil {
-		return false, err
+	cache := matchers()
+	m, ok := cache.get(pat)
+	if !ok {
+		m = new(matcher.Matcher)
+		if _, err := m.Decode(reflect.TypeOf(pat), pat); err != nil {
+			return false, err
+		}
+		cache.add(pat, m)
 	}
 	return m.Match(input), nil
 }
 
-func upTo(max, input interface{}) int {
-	value := toInt(input)
-	maximum := toInt(max)
-	if value > maximum {
-		return maximum
+func upTo(limit, input interface{}) (int, error) {
+	value, maximum, err := toInts(input, limit)
+	if err != nil {
+		return 0, err
 	}
-	return value
+	return min(value, maximum), nil
 }
 
-func downTo(min, input interface{}) int {
-	value := toInt(input)
-	minimum := toInt(min)
-	if value < minimum {
-		return minimum
+func downTo(limit, input interface{}) (int, error) {
+	value, minimum, err := toInts(input, limit)
+	if err != nil {
+		return 0, err
+	}
+	return max(value, minimum), nil
+}
+
+func toInts(a, b any) (int, int, error) {
+	x, err := toInt(a)
+	if err != nil {
+		return 0, 0, err
+	}
+	y, err := toInt(b)
+	if err != nil {
+		return 0, 0, err
 	}
-	return value
+	return x, y, nil
 }
 
 func readFile(path string) (string, error) {
@@ -241,10 +251,10 @@ func readFile(path string) (string, error) {
 	return string(data), nil
 }
 
-func parseDuration(dur string) int64 {
+func parseDuration(dur string) (int64, error) {
 	d, err := time.ParseDuration(dur)
 	if err != nil {
-		panic(err)
+		return 0, err
 	}
-	return int64(d)
+	return int64(d), nil
 }
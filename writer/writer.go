@@ -1,11 +1,11 @@
 package writer
 
 import (
-	"errors"
 	"io"
 	"reflect"
-	"strings"
+	"sync"
 
+	"github.com/fmotalleb/go-tools/decoder/variant"
 	"github.com/fmotalleb/go-tools/log"
 )
 
@@ -18,66 +18,65 @@ type writer interface {
 	io.Writer
 }
 
-func (w *Writer) Decode(from reflect.Type, val interface{}) (any, error) {
-	var ty, path string
-	params := make(map[string]any)
-	switch from.Kind() {
-	case reflect.String:
-		strVal := val.(string)
-		split := strings.SplitN(strVal, ",", 2)
-		params["type"] = split[0]
-		if len(split) == 2 {
-			params["path"] = split[1]
-		} else {
-			params["path"] = ""
-		}
-	case reflect.Slice:
-		arr := val.([]any)
-		if len(arr) == 0 {
-			break
-		}
-		params["type"] = arr[0]
-		if len(arr) == 2 {
-			params["path"] = arr[1]
-		} else {
-			params["path"] = ""
-		}
-	case reflect.Map:
-		if from.Key().Kind() != reflect.String {
-			return nil, errors.New("map key must be a string")
-		}
-		params = val.(map[string]interface{})
-	default:
-		return nil, errors.New("unsupported type for writer")
-	}
-	w.definition = val
-	var ok bool
-	ty, ok = params["type"].(string)
-	if !ok {
-		return nil, errors.New("`type` key is required in map, as string")
-	}
-	path, ok = params["path"].(string)
-	if !ok {
-		return nil, errors.New("`path` key is required in map, as string")
-	}
+type zapOptions struct {
+	// Name of the logger, read from `path` to keep the `zap,name` form working.
+	Name string `mapstructure:"path"`
+}
 
-	switch ty {
-	case "stderr", "std", "":
-		w.writer = NewStdErr()
-	case "zap", "log":
+type rotateOptions struct {
+	Path      string `mapstructure:"path"`
+	MaxSize   int    `mapstructure:"max_size"`
+	MaxAge    int    `mapstructure:"max_age"`
+	Compress  bool   `mapstructure:"compress"`
+	LocalTime bool   `mapstructure:"local_time"`
+}
+
+var variants = sync.OnceValue(func() *variant.Registry[io.Writer] {
+	r := variant.NewRegistry[io.Writer](variant.WithSeparators(":", ","))
+	variant.Register(r, "stderr", func(struct{}) (io.Writer, error) {
+		return NewStdErr(), nil
+	}, variant.WithAliases("std", ""), variant.WithArgs("path"))
+	variant.Register(r, "zap", func(opts zapOptions) (io.Writer, error) {
 		b := log.NewBuilder().FromEnv()
-		if path != "" {
-			b = b.Name(path)
+		if opts.Name != "" {
+			b = b.Name(opts.Name)
 		}
 		l, err := b.Build()
 		if err != nil {
 			return nil, err
 		}
-		w.writer = NewZapWriter(l)
-	case "rotate", "rotated", "file":
-		w.writer = NewRotateWriter(RotateFileName(path))
-	default:
-		return nil, errors.New("unknown writer type: " + ty)
+		return NewZapWriter(l), nil
+	}, variant.WithAliases("log"), variant.WithArgs("path"))
+	variant.Register(r, "rotate", func(opts rotateOptions) (io.Writer, error) {
+		return NewRotateWriter(
+			RotateFileName(opts.Path),
+			RotateMaxSize(opts.MaxSize),
+			RotateMaxAge(opts.MaxAge),
+			RotateCompress(opts.Compress),
+			RotateLocalTime(opts.LocalTime),
+		), nil
+	}, variant.WithAliases("rotated", "file"), variant.WithArgs("path"))
+	return r
+})
+
+// Variants returns the writer kinds registry, new kinds registered there are accepted by [Writer.Decode].
+func Variants() *variant.Registry[io.Writer] {
+	return variants()
+}
+
+// Decode builds the writer from `type:path` or `type,path` strings, `[type, path]` lists or
+// `{type: rotate, path: ..., max_size: 10}` maps. Known types are stderr, zap and rotate.
+func (w *Writer) Decode(_ reflect.Type, val interface{}) (any, error) {
+	out, err := variants().Decode(val, nil)
+	if err != nil {
+		return nil, err
 	}
+	w.writer = out
+	w.definition = val
 	return w, nil
 }
+
+// Encode returns the definition the writer was decoded from, so it encodes back into the same config.
+func (w *Writer) Encode() (any, error) {
+	return w.definition, nil
+}